@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListIncidentEvents lists the notifications which need attention, failed and dead lettered ones by default
+func (b *BaseController) ListIncidentEvents(ctx *gin.Context) {
+	var (
+		request            ListIncidentEventsRequest
+		incidentEventsRepo = models.InitIncidentEventsRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListIncidentEventsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	statuses := []models.EventStatus{models.EventStatusFailed, models.EventStatusDeadLetter}
+	if request.EventStatus != "" {
+		statuses = []models.EventStatus{request.EventStatus}
+	}
+
+	events, total, err := incidentEventsRepo.ListByStatus(ctx, statuses, request.PageSize, request.offset())
+	if err != nil {
+		logger.Error("error in listing incident events | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListIncidentEventsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListIncidentEventsResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Incident events fetched successfully.",
+		Data:     events,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
+
+// ReplayIncidentEvent schedules a failed or dead lettered notification for immediate delivery with a fresh
+// set of attempts, the notification job picks it up on its next retry poll
+func (b *BaseController) ReplayIncidentEvent(ctx *gin.Context) {
+	var (
+		incidentEventsRepo = models.InitIncidentEventsRepo(b.DB)
+		uuid               = ctx.Param("uuid")
+	)
+
+	//an empty uuid would match any event
+	if uuid == "" {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, IncidentEventResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Incident event not found",
+		})
+		return
+	}
+
+	incidentEvent, err := incidentEventsRepo.GetWithTx(b.DB.WithContext(ctx), &models.IncidentEvent{UUID: uuid})
+	if err == gorm.ErrRecordNotFound {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, IncidentEventResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Incident event not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.Error("error in fetching incident event | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, IncidentEventResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	if incidentEvent.EventStatus != models.EventStatusFailed && incidentEvent.EventStatus != models.EventStatusDeadLetter {
+		ctx.AbortWithStatusJSON(http.StatusConflict, IncidentEventResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Only failed or dead lettered events can be replayed",
+		})
+		return
+	}
+
+	now := time.Now()
+	incidentEvent.EventStatus = models.EventStatusFailed
+	incidentEvent.AttemptCount = 0
+	incidentEvent.NextAttemptAt = &now
+	err = incidentEventsRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.IncidentEvent{ID: incidentEvent.ID}, incidentEvent,
+		"event_status", "attempt_count", "next_attempt_at")
+	if err != nil {
+		logger.Error("error in scheduling incident event replay | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, IncidentEventResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, IncidentEventResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Incident event scheduled for replay.",
+		Data:    incidentEvent,
+	})
+}
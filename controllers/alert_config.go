@@ -1,55 +1,154 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/assertions"
 	"github.com/ankur12345678/uptime-monitor/pkg/constants"
 	"github.com/ankur12345678/uptime-monitor/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// getOwnedAlertConfig fetches the alert config of the :uuid website, scoped to the authenticated user.
+// The returned code is the http status the caller should abort with in case of an error.
+func (b *BaseController) getOwnedAlertConfig(ctx *gin.Context) (*models.Website, *models.AlertConfig, int, error) {
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		return nil, nil, code, err
+	}
+
+	alertConfig, err := models.InitAlertConfigRepo(b.DB).GetWithTx(b.DB.WithContext(ctx), &models.AlertConfig{WebsiteID: website.ID})
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	return website, alertConfig, http.StatusOK, nil
+}
+
+func (b *BaseController) GetAlertConfig(c *gin.Context) {
+	_, alertConfig, code, err := b.getOwnedAlertConfig(c)
+	if err != nil {
+		logger.Error("error in fetching alert config | err: ", err)
+		c.AbortWithStatusJSON(code, AlertConfigResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, AlertConfigResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Alert config fetched successfully.",
+		Data:    alertConfig,
+	})
+}
+
 func (b *BaseController) UpdateAlertConfig(c *gin.Context) {
 	var (
 		request         = UpdateAlertConfigRequest{}
 		alertConfigRepo = models.InitAlertConfigRepo(b.DB)
+		columns         []string
 	)
 
 	err := c.ShouldBindJSON(&request)
 	if err != nil {
 		logger.Error("error in binding request | err: ", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, UpdateAlertConfigResponse{
+		c.AbortWithStatusJSON(http.StatusBadRequest, AlertConfigResponse{
 			Status:  constants.GENERIC_FAILURE_RESPONSE,
-			Message: "Something went wrong. Please try again",
+			Message: "Please enter valid details",
 		})
 		return
 	}
 
-	//TODO: validate request
-
-	config, err := alertConfigRepo.GetWithTx(b.DB, &models.AlertConfig{WebsiteID: request.WebsiteId})
-	if err != nil {
-		logger.Error("error in fetching config from DB | err: ", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, UpdateAlertConfigResponse{
-			Status:  constants.GENERIC_FAILURE_RESPONSE,
-			Message: "Something went wrong. Please try again",
+	validationErrors := b.ValidateRequest(&request)
+	if request.Assertions != nil {
+		for i, assertion := range *request.Assertions {
+			err := assertions.Validate(assertion)
+			if err != nil {
+				validationErrors = append(validationErrors, constants.Error{
+					Field:       fmt.Sprintf("assertions[%d]", i),
+					Description: err.Error(),
+				})
+			}
+		}
+	}
+	if len(validationErrors) != 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
 		})
 		return
 	}
 
-	//TODO: validate the updation of falsy values like FALSE
-	err = alertConfigRepo.Update(&models.AlertConfig{ID: config.ID}, &models.AlertConfig{IsEnabled: request.IsEnabled, LatencyThreshold: int(request.LatencyThreshold), FailureThreshold: int(request.FailureThreshold)})
+	_, alertConfig, code, err := b.getOwnedAlertConfig(c)
 	if err != nil {
-		logger.Error("error in updating config in DB | err: ", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, UpdateAlertConfigResponse{
+		logger.Error("error in fetching alert config | err: ", err)
+		c.AbortWithStatusJSON(code, AlertConfigResponse{
 			Status:  constants.GENERIC_FAILURE_RESPONSE,
-			Message: "Something went wrong. Please try again",
+			Message: websiteErrorMessage(code),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, UpdateAlertConfigResponse{
+	//only the fields present in the request are selected, so false/0 are written as well
+	if request.IsEnabled != nil {
+		alertConfig.IsEnabled = *request.IsEnabled
+		columns = append(columns, "is_enabled")
+	}
+	if request.FailureThreshold != nil {
+		alertConfig.FailureThreshold = *request.FailureThreshold
+		columns = append(columns, "failure_threshold")
+	}
+	if request.LatencyThreshold != nil {
+		alertConfig.LatencyThreshold = *request.LatencyThreshold
+		columns = append(columns, "latency_threshold")
+	}
+	if request.LocationQuorum != nil {
+		alertConfig.LocationQuorum = *request.LocationQuorum
+		columns = append(columns, "location_quorum")
+	}
+	if request.CertExpiryAlertDays != nil {
+		alertConfig.CertExpiryAlertDays = *request.CertExpiryAlertDays
+		columns = append(columns, "cert_expiry_alert_days")
+	}
+	if request.Assertions != nil {
+		alertConfig.Assertions = *request.Assertions
+		columns = append(columns, "assertions")
+	}
+	if request.MuteForMinutes != nil {
+		alertConfig.MutedUntil = nil
+		if *request.MuteForMinutes > 0 {
+			mutedUntil := time.Now().Add(time.Duration(*request.MuteForMinutes) * time.Minute)
+			alertConfig.MutedUntil = &mutedUntil
+		}
+		columns = append(columns, "muted_until")
+	}
+	if request.EscalateAfterMinutes != nil {
+		alertConfig.EscalationPolicy.EscalateAfterMinutes = *request.EscalateAfterMinutes
+		columns = append(columns, "escalation_escalate_after_minutes")
+	}
+	if request.RepeatEveryMinutes != nil {
+		alertConfig.EscalationPolicy.RepeatEveryMinutes = *request.RepeatEveryMinutes
+		columns = append(columns, "escalation_repeat_every_minutes")
+	}
+
+	if len(columns) != 0 {
+		err = alertConfigRepo.UpdateSelectedWithTx(b.DB.WithContext(c), &models.AlertConfig{ID: alertConfig.ID}, alertConfig, columns...)
+		if err != nil {
+			logger.Error("error in updating config in DB | err: ", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, AlertConfigResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "Something went wrong. Please try again",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, AlertConfigResponse{
 		Status:  constants.GENERIC_SUCCESS_RESPONSE,
 		Message: "Updated successfully.",
+		Data:    alertConfig,
 	})
 }
@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/sendgrid"
+	"github.com/ankur12345678/uptime-monitor/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func alertTargetErrorMessage(code int) string {
+	if code == http.StatusNotFound {
+		return "Alert target not found"
+	}
+	return "Something went wrong. Please try again"
+}
+
+// getOwnedAlertTarget fetches the :id alert target of the :uuid website, scoped to the authenticated user
+func (b *BaseController) getOwnedAlertTarget(ctx *gin.Context) (*models.AlertTarget, int, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+
+	_, alertConfig, code, err := b.getOwnedAlertConfig(ctx)
+	if err != nil {
+		return nil, code, err
+	}
+
+	target, err := models.InitAlertTargetRepo(b.DB).GetWithTx(b.DB.WithContext(ctx), &models.AlertTarget{ID: uint(id), AlertConfigID: alertConfig.ID})
+	if err == gorm.ErrRecordNotFound {
+		return nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return target, http.StatusOK, nil
+}
+
+func (b *BaseController) ListAlertTargets(ctx *gin.Context) {
+	var (
+		alertTargetRepo = models.InitAlertTargetRepo(b.DB.WithContext(ctx))
+	)
+
+	_, alertConfig, code, err := b.getOwnedAlertConfig(ctx)
+	if err != nil {
+		logger.Error("error in fetching alert config | err: ", err)
+		ctx.AbortWithStatusJSON(code, ListAlertTargetsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	targets, err := alertTargetRepo.ListByAlertConfigID(alertConfig.ID)
+	if err != nil {
+		logger.Error("error in listing alert targets | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListAlertTargetsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListAlertTargetsResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Alert targets fetched successfully.",
+		Data:    targets,
+	})
+}
+
+func (b *BaseController) CreateAlertTarget(ctx *gin.Context) {
+	var (
+		request         CreateAlertTargetRequest
+		alertTargetRepo = models.InitAlertTargetRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	validationErrors := b.ValidateRequest(&request)
+	if validationErrors == nil && !b.isValidTargetValue(request.TargetType, request.TargetValue) {
+		validationErrors = []constants.Error{{Field: "target_value", Description: "target_value should be an email for email targets, an E.164 phone number for sms/voice targets and an https url otherwise"}}
+	}
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	website, alertConfig, code, err := b.getOwnedAlertConfig(ctx)
+	if err != nil {
+		logger.Error("error in fetching alert config | err: ", err)
+		ctx.AbortWithStatusJSON(code, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	existing, err := alertTargetRepo.GetWithTx(b.DB.WithContext(ctx), &models.AlertTarget{AlertConfigID: alertConfig.ID, TargetType: request.TargetType, TargetValue: request.TargetValue})
+	if err == nil {
+		if existing.TargetType != models.TargetTypeEmail || existing.VerifiedAt != nil {
+			ctx.AbortWithStatusJSON(http.StatusConflict, AlertTargetResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "Alert target already exists",
+			})
+			return
+		}
+
+		//an unconfirmed email gets a new link, the earlier one may have expired or been lost
+		if existing.VerificationSentAt != nil &&
+			time.Since(*existing.VerificationSentAt) < constants.ALERT_TARGET_VERIFICATION_RESEND_MINUTES*time.Minute {
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, AlertTargetResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "A confirmation link was sent recently. Please check the email",
+			})
+			return
+		}
+		b.createEmailAlertTarget(ctx, website, existing)
+		return
+	}
+	if err != gorm.ErrRecordNotFound {
+		logger.Error("error in fetching alert target | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	if request.EscalationLevel == 0 {
+		request.EscalationLevel = 1
+	}
+
+	target := &models.AlertTarget{
+		EscalationLevel: request.EscalationLevel,
+		TargetType:      request.TargetType,
+		TargetValue:     request.TargetValue,
+		IsActive:        false,
+		AlertConfigID:   alertConfig.ID,
+	}
+
+	if request.TargetType.IsWebhook() || request.TargetType.IsPhone() {
+		b.createWebhookAlertTarget(ctx, target)
+		return
+	}
+	b.createEmailAlertTarget(ctx, website, target)
+}
+
+// isValidTargetValue checks the target value against what the target type delivers to
+func (b *BaseController) isValidTargetValue(targetType models.TargetType, value string) bool {
+	if targetType == models.TargetTypeEmail {
+		return b.Validator.Var(value, "email") == nil
+	}
+	if targetType.IsPhone() {
+		return b.Validator.Var(value, "e164phonenumber") == nil
+	}
+	return b.Validator.Var(value, "url") == nil && strings.HasPrefix(value, "https://")
+}
+
+// createEmailAlertTarget mails a confirmation link to the email target, it stays inactive until the link is
+// opened. A target which already exists (non zero ID) is given a new link in place of its earlier one.
+func (b *BaseController) createEmailAlertTarget(ctx *gin.Context, website *models.Website, target *models.AlertTarget) {
+	var (
+		alertTargetRepo = models.InitAlertTargetRepo(b.DB)
+	)
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		logger.Error("error in generating verification token | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	now := time.Now()
+	target.VerificationToken = token
+	target.VerificationSentAt = &now
+
+	//target (or its new link) is only kept if the confirmation email could be sent
+	resend := target.ID != 0
+	err = b.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if resend {
+			err = alertTargetRepo.UpdateSelectedWithTx(tx, &models.AlertTarget{ID: target.ID}, target,
+				"verification_token", "verification_sent_at")
+		} else {
+			err = alertTargetRepo.CreateWithTx(tx, target)
+		}
+		if err != nil {
+			return err
+		}
+
+		return sendgrid.SendVerificationEmail(target.TargetValue, b.Config, sendgrid.LinkEmailData{
+			WebsiteURL: website.WebsiteURL,
+			Link:       fmt.Sprintf("%s/v1/alert-targets/verify/%s", b.Config.ServerBaseUrl, token),
+			Year:       now.Year(),
+		})
+	})
+	if err != nil {
+		logger.Error("error in creating alert target | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	message := "Alert target added. Please confirm it using the link sent on the email."
+	if resend {
+		message = "Alert target is not confirmed yet. Please confirm it using the new link sent on the email."
+	}
+	ctx.JSON(http.StatusOK, AlertTargetResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: message,
+		Data:    target,
+	})
+}
+
+// createWebhookAlertTarget activates webhook and phone targets right away, generic webhooks also get a signing secret
+func (b *BaseController) createWebhookAlertTarget(ctx *gin.Context, target *models.AlertTarget) {
+	var (
+		alertTargetRepo = models.InitAlertTargetRepo(b.DB)
+		signingSecret   string
+	)
+
+	if target.TargetType == models.TargetTypeWebhook {
+		secret, err := utils.GenerateToken()
+		if err == nil {
+			target.EncryptedSigningSecret, err = utils.EncryptString(b.Config.EncryptionKey, secret)
+		}
+		if err != nil {
+			logger.Error("error in generating webhook signing secret | err: ", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "Something went wrong. Please try again",
+			})
+			return
+		}
+		signingSecret = secret
+	}
+
+	target.IsActive = true
+	err := alertTargetRepo.CreateWithTx(b.DB.WithContext(ctx), target)
+	if err != nil {
+		logger.Error("error in creating alert target | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, AlertTargetResponse{
+		Status:        constants.GENERIC_SUCCESS_RESPONSE,
+		Message:       "Alert target added successfully.",
+		Data:          target,
+		SigningSecret: signingSecret,
+	})
+}
+
+// VerifyAlertTarget is opened from the confirmation email, the token itself authenticates the request
+func (b *BaseController) VerifyAlertTarget(ctx *gin.Context) {
+	var (
+		alertTargetRepo = models.InitAlertTargetRepo(b.DB)
+		token           = ctx.Param("token")
+	)
+
+	//an empty token would match every target as zero values are ignored in the where clause
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "The link is invalid or has expired",
+		})
+		return
+	}
+
+	target, err := alertTargetRepo.GetWithTx(b.DB.WithContext(ctx), &models.AlertTarget{VerificationToken: token})
+	if err != nil && err != gorm.ErrRecordNotFound {
+		logger.Error("error in fetching alert target | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	expired := err == nil && target.VerificationSentAt != nil &&
+		time.Since(*target.VerificationSentAt) > constants.ALERT_TARGET_VERIFICATION_TTL_HOURS*time.Hour
+	if err == gorm.ErrRecordNotFound || expired {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "The link is invalid or has expired",
+		})
+		return
+	}
+
+	now := time.Now()
+	err = alertTargetRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.AlertTarget{ID: target.ID},
+		&models.AlertTarget{IsActive: true, VerifiedAt: &now, VerificationToken: ""},
+		"is_active", "verified_at", "verification_token")
+	if err != nil {
+		logger.Error("error in verifying alert target | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, AlertTargetResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Email confirmed. You will now receive alerts on it.",
+	})
+}
+
+func (b *BaseController) ActivateAlertTarget(ctx *gin.Context) {
+	b.setAlertTargetActive(ctx, true)
+}
+
+func (b *BaseController) DeactivateAlertTarget(ctx *gin.Context) {
+	b.setAlertTargetActive(ctx, false)
+}
+
+func (b *BaseController) setAlertTargetActive(ctx *gin.Context, active bool) {
+	var (
+		alertTargetRepo = models.InitAlertTargetRepo(b.DB)
+	)
+
+	target, code, err := b.getOwnedAlertTarget(ctx)
+	if err != nil {
+		logger.Error("error in fetching alert target | err: ", err)
+		ctx.AbortWithStatusJSON(code, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: alertTargetErrorMessage(code),
+		})
+		return
+	}
+
+	if active && target.TargetType == models.TargetTypeEmail && target.VerifiedAt == nil {
+		ctx.AbortWithStatusJSON(http.StatusConflict, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please confirm the email before activating it",
+		})
+		return
+	}
+
+	err = alertTargetRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.AlertTarget{ID: target.ID}, &models.AlertTarget{IsActive: active}, "is_active")
+	if err != nil {
+		logger.Error("error in updating alert target | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	target.IsActive = active
+
+	ctx.JSON(http.StatusOK, AlertTargetResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Alert target updated successfully.",
+		Data:    target,
+	})
+}
+
+func (b *BaseController) DeleteAlertTarget(ctx *gin.Context) {
+	var (
+		alertTargetRepo = models.InitAlertTargetRepo(b.DB)
+	)
+
+	target, code, err := b.getOwnedAlertTarget(ctx)
+	if err != nil {
+		logger.Error("error in fetching alert target | err: ", err)
+		ctx.AbortWithStatusJSON(code, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: alertTargetErrorMessage(code),
+		})
+		return
+	}
+
+	err = alertTargetRepo.DeleteWithTx(b.DB.WithContext(ctx), &models.AlertTarget{ID: target.ID})
+	if err != nil {
+		logger.Error("error in deleting alert target | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, AlertTargetResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, AlertTargetResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Alert target deleted successfully.",
+	})
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"testing"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/validator"
+)
+
+func TestIsValidTargetValuePhone(t *testing.T) {
+	validate, _, err := validator.InitValidator()
+	if err != nil {
+		t.Fatalf("unable to init validator: %v", err)
+	}
+	b := &BaseController{Validator: validate}
+
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "+14155552671", want: true},
+		{value: "+447911123456", want: true},
+		{value: "+919876543210", want: true},
+		{value: "14155552671", want: false},     //missing +
+		{value: "+1 415 555 2671", want: false}, //not normalized
+		{value: "+1-415-555-2671", want: false},
+		{value: "+1415555267", want: false},  //too short
+		{value: "+10005552671", want: false}, //invalid area code
+		{value: "", want: false},
+		{value: "https://example.com", want: false},
+	}
+
+	for _, targetType := range []models.TargetType{models.TargetTypeSMS, models.TargetTypeVoice} {
+		for _, tt := range tests {
+			got := b.isValidTargetValue(targetType, tt.value)
+			if got != tt.want {
+				t.Errorf("isValidTargetValue(%s, %q) = %v, want %v", targetType, tt.value, got, tt.want)
+			}
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 
 	config "github.com/ankur12345678/uptime-monitor/Config"
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
 	"github.com/gin-gonic/gin"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -30,3 +33,36 @@ func GetEmailFromContext(ctx *gin.Context) (string, error) {
 
 	return email.(string), nil
 }
+
+// GetUserFromContext fetches the authenticated user set by the auth middleware
+func (b *BaseController) GetUserFromContext(ctx *gin.Context) (*models.User, error) {
+	email, err := GetEmailFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.InitUserRepo(b.DB).GetByEmail(email)
+}
+
+// ValidateRequest runs the struct validations and translates the failures, nil if the request is valid
+func (b *BaseController) ValidateRequest(request interface{}) []constants.Error {
+	err := b.Validator.Struct(request)
+	if err == nil {
+		return nil
+	}
+
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []constants.Error{{Description: err.Error()}}
+	}
+
+	validationErrors := []constants.Error{}
+	for _, err := range fieldErrors {
+		logger.Error("error in validating the request | err", err)
+		validationErrors = append(validationErrors, constants.Error{
+			Field:       err.Field(),
+			Description: err.Translate(*b.Translator),
+		})
+	}
+	return validationErrors
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ListWebsiteStepLogs returns the per step results of a flow, each step log points at the log of its check
+func (b *BaseController) ListWebsiteStepLogs(ctx *gin.Context) {
+	var (
+		request  PaginationRequest
+		logsRepo = models.InitLogsRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListStepLogsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, ListStepLogsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	steps, total, err := logsRepo.ListStepLogsByWebsiteID(ctx, website.ID, request.PageSize, request.offset())
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListStepLogsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListStepLogsResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Step logs fetched successfully.",
+		Data:     steps,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// parsePingSignal reads the optional :signal of a ping, it is start, success, fail or the exit code of the
+// run where 0 is a success and anything else a failure. A ping without a signal is a success.
+func parsePingSignal(signal string) (models.HeartbeatSignal, *int, bool) {
+	switch models.HeartbeatSignal(signal) {
+	case "", models.HeartbeatSignalSuccess:
+		return models.HeartbeatSignalSuccess, nil, true
+	case models.HeartbeatSignalStart, models.HeartbeatSignalFail:
+		return models.HeartbeatSignal(signal), nil, true
+	}
+
+	exitCode, err := strconv.Atoi(signal)
+	if err != nil || exitCode < 0 || exitCode > 255 {
+		return "", nil, false
+	}
+	if exitCode == 0 {
+		return models.HeartbeatSignalSuccess, &exitCode, true
+	}
+	return models.HeartbeatSignalFail, &exitCode, true
+}
+
+// readPingExcerpt keeps the start of the body of the ping, as text which postgres accepts
+func readPingExcerpt(body io.Reader) string {
+	if body == nil {
+		return ""
+	}
+	excerpt, err := io.ReadAll(io.LimitReader(body, constants.HEARTBEAT_EXCERPT_MAX_BYTES))
+	if err != nil {
+		logger.Error("error in reading ping body | err: ", err)
+	}
+	return strings.ReplaceAll(strings.ToValidUTF8(string(excerpt), ""), "\x00", "")
+}
+
+// Ping is called by the job monitored by a heartbeat, the token itself authenticates the request. The sweep of the
+// website picker opens or resolves the incident, see SweepHeartbeats.
+func (b *BaseController) Ping(ctx *gin.Context) {
+	var (
+		websiteRepo        = models.InitWebsiteRepo(b.DB)
+		heartbeatPingsRepo = models.InitHeartbeatPingsRepo(b.DB)
+		logsRepo           = models.InitLogsRepo(b.DB)
+		token              = ctx.Param("token")
+	)
+
+	signal, exitCode, ok := parsePingSignal(ctx.Param("signal"))
+	//an empty token would match every website as zero values are ignored in the where clause
+	if token == "" || !ok {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, PingResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Invalid ping",
+		})
+		return
+	}
+
+	website, err := websiteRepo.GetWithTx(&models.Website{HeartbeatToken: &token, CheckType: models.CheckTypeHeartbeat}, b.DB.WithContext(ctx))
+	if err == gorm.ErrRecordNotFound {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, PingResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Heartbeat not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.Error("error in fetching heartbeat | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, PingResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	now := time.Now()
+	ping := &models.HeartbeatPing{
+		WebsiteId: website.ID,
+		Signal:    signal,
+		ExitCode:  exitCode,
+		Excerpt:   readPingExcerpt(ctx.Request.Body),
+		SourceIP:  ctx.ClientIP(),
+	}
+	updates := &models.Website{LastPingAt: &now, LastPingSignal: signal}
+
+	if signal == models.HeartbeatSignalStart {
+		//the run has to finish within the grace time
+		pingDueAt := now.Add(time.Duration(website.HeartbeatGraceSeconds) * time.Second)
+		updates.PingDueAt = &pingDueAt
+		updates.NextCheckAt = pingDueAt
+	} else {
+		if website.LastPingSignal == models.HeartbeatSignalStart && website.LastPingAt != nil {
+			duration := now.Sub(*website.LastPingAt).Milliseconds()
+			ping.DurationInMS = &duration
+		}
+		pingDueAt := now.Add(website.HeartbeatPeriod())
+		updates.PingDueAt = &pingDueAt
+		//the next sweep opens or resolves the incident right away
+		updates.NextCheckAt = now
+	}
+
+	err = b.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := heartbeatPingsRepo.CreateWithTx(tx, ping)
+		if err != nil {
+			return err
+		}
+		return websiteRepo.UpdateSelectedWithTx(tx, &models.Website{ID: website.ID}, updates,
+			"last_ping_at", "last_ping_signal", "ping_due_at", "next_check_at")
+	})
+	if err != nil {
+		logger.Error("error in recording ping | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, PingResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	//finished runs count as checks of the heartbeat, for its stats and status pages
+	if signal != models.HeartbeatSignalStart {
+		inMaintenance, err := models.InitMaintenanceWindowsRepo(b.DB).CoversWebsite(b.DB.WithContext(ctx), website.UserId, website.ID, now)
+		if err != nil {
+			logger.Error("error in fetching maintenance windows | err: ", err)
+		}
+
+		log := models.Log{
+			WebsiteId:     website.ID,
+			HealthStatus:  string(models.Healthy),
+			Location:      models.HeartbeatLocation,
+			InMaintenance: inMaintenance,
+		}
+		if ping.DurationInMS != nil {
+			log.LatencyInMS = uint(*ping.DurationInMS)
+		}
+		if signal == models.HeartbeatSignalFail {
+			log.HealthStatus = string(models.Unhealthy)
+			log.FailureReason = ping.FailureReason()
+		}
+		//the ping itself is recorded, a missing log only affects the stats
+		_ = logsRepo.Create(ctx, log)
+	}
+
+	ctx.JSON(http.StatusOK, PingResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "OK",
+	})
+}
+
+func (b *BaseController) ListHeartbeatPings(ctx *gin.Context) {
+	var (
+		request            PaginationRequest
+		heartbeatPingsRepo = models.InitHeartbeatPingsRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListHeartbeatPingsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, ListHeartbeatPingsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	pings, total, err := heartbeatPingsRepo.ListByWebsiteID(ctx, website.ID, request.PageSize, request.offset())
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListHeartbeatPingsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListHeartbeatPingsResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Heartbeat pings fetched successfully.",
+		Data:     pings,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (b *BaseController) ListWebsiteIncidents(ctx *gin.Context) {
+	var (
+		request       PaginationRequest
+		incidentsRepo = models.InitIncidentsRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListIncidentsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, ListIncidentsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	incidents, total, err := incidentsRepo.ListByWebsiteID(ctx, website.ID, request.PageSize, request.offset())
+	if err != nil {
+		logger.Error("error in listing incidents | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListIncidentsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListIncidentsResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Incidents fetched successfully.",
+		Data:     incidents,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
+
+// getOwnedIncident fetches the :id incident, scoped to the websites of the authenticated user
+func (b *BaseController) getOwnedIncident(ctx *gin.Context) (*models.Incident, *models.User, int, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return nil, nil, http.StatusNotFound, err
+	}
+
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	incident, err := models.InitIncidentsRepo(b.DB).GetWithTx(b.DB.WithContext(ctx), &models.Incident{ID: uint(id)})
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	_, err = models.InitWebsiteRepo(b.DB).GetWithTx(&models.Website{ID: incident.WebsiteId, UserId: user.ID}, b.DB.WithContext(ctx))
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	return incident, user, http.StatusOK, nil
+}
+
+func incidentErrorMessage(code int) string {
+	if code == http.StatusNotFound {
+		return "Incident not found"
+	}
+	return "Something went wrong. Please try again"
+}
+
+func (b *BaseController) AcknowledgeIncident(ctx *gin.Context) {
+	incident, user, code, err := b.getOwnedIncident(ctx)
+	if err != nil {
+		logger.Error("error in fetching incident | err: ", err)
+		ctx.AbortWithStatusJSON(code, AcknowledgeIncidentResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: incidentErrorMessage(code),
+		})
+		return
+	}
+
+	code, message := b.acknowledgeIncident(ctx, incident.ID, user.Email)
+	status := constants.GENERIC_SUCCESS_RESPONSE
+	if code != http.StatusOK {
+		status = constants.GENERIC_FAILURE_RESPONSE
+	}
+	ctx.JSON(code, AcknowledgeIncidentResponse{
+		Status:  status,
+		Message: message,
+	})
+}
+
+// incidentAckPage is shown for the signed link sent in the down notification. Opening the link only asks for a
+// confirmation, mail scanners and link previews fetch it without anyone reading the alert.
+var incidentAckPage = template.Must(template.New("incident_ack").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Acknowledge incident</title>
+</head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 48px auto; padding: 0 16px;">
+<p>{{.Message}}</p>
+{{if .Action}}<form method="POST" action="{{.Action}}">
+<input type="hidden" name="expires" value="{{.Expires}}">
+<input type="hidden" name="signature" value="{{.Signature}}">
+<button type="submit">Acknowledge incident</button>
+</form>{{end}}
+</body>
+</html>`))
+
+type incidentAckPageData struct {
+	Message   string
+	Action    string
+	Expires   string
+	Signature string
+}
+
+func writeIncidentAckPage(ctx *gin.Context, code int, data incidentAckPageData) {
+	var body bytes.Buffer
+	err := incidentAckPage.Execute(&body, data)
+	if err != nil {
+		logger.Error("error in rendering incident acknowledgement page | err: ", err)
+		ctx.String(http.StatusInternalServerError, "Something went wrong. Please try again")
+		return
+	}
+	ctx.Data(code, "text/html; charset=utf-8", body.Bytes())
+}
+
+// verifyIncidentAckLink returns the incident of a signed acknowledgement link, expires and signature are
+// read from the query of the link or from the confirmation form
+func (b *BaseController) verifyIncidentAckLink(ctx *gin.Context, expiresValue, signature string) (uint, bool) {
+	id, idErr := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	expires, expiresErr := strconv.ParseInt(expiresValue, 10, 64)
+	if idErr != nil || expiresErr != nil || !utils.VerifyIncidentAckSignature(b.Config.IncidentAckSecret, uint(id), expires, signature) {
+		logger.Error("invalid or expired incident acknowledgement link")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ConfirmIncidentAckLink handles the signed link sent in the down notification, it only renders the
+// confirmation which posts to AcknowledgeIncidentFromLink
+func (b *BaseController) ConfirmIncidentAckLink(ctx *gin.Context) {
+	incidentID, ok := b.verifyIncidentAckLink(ctx, ctx.Query("expires"), ctx.Query("signature"))
+	if !ok {
+		writeIncidentAckPage(ctx, http.StatusForbidden, incidentAckPageData{Message: "This link is invalid or has expired"})
+		return
+	}
+
+	writeIncidentAckPage(ctx, http.StatusOK, incidentAckPageData{
+		Message:   "Acknowledging the incident stops further escalations and reminders.",
+		Action:    fmt.Sprintf("/v1/incidents/%d/ack/confirm", incidentID),
+		Expires:   ctx.Query("expires"),
+		Signature: ctx.Query("signature"),
+	})
+}
+
+// AcknowledgeIncidentFromLink handles the confirmation of ConfirmIncidentAckLink
+func (b *BaseController) AcknowledgeIncidentFromLink(ctx *gin.Context) {
+	incidentID, ok := b.verifyIncidentAckLink(ctx, ctx.PostForm("expires"), ctx.PostForm("signature"))
+	if !ok {
+		writeIncidentAckPage(ctx, http.StatusForbidden, incidentAckPageData{Message: "This link is invalid or has expired"})
+		return
+	}
+
+	code, message := b.acknowledgeIncident(ctx, incidentID, "email-link")
+	writeIncidentAckPage(ctx, code, incidentAckPageData{Message: message})
+}
+
+// acknowledgeIncident stops further escalations/reminders for an open incident, it returns the status code
+// and message of the response
+func (b *BaseController) acknowledgeIncident(ctx *gin.Context, incidentID uint, acknowledgedBy string) (int, string) {
+	acknowledged, err := models.InitIncidentsRepo(b.DB).Acknowledge(b.DB.WithContext(ctx), incidentID, acknowledgedBy)
+	if err != nil {
+		logger.Error("error in acknowledging incident | err: ", err)
+		return http.StatusInternalServerError, "Something went wrong. Please try again"
+	}
+
+	if !acknowledged {
+		return http.StatusConflict, "Incident is already acknowledged or resolved"
+	}
+	return http.StatusOK, "Incident acknowledged successfully."
+}
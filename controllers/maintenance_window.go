@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/schedule"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func maintenanceWindowErrorMessage(code int) string {
+	if code == http.StatusNotFound {
+		return "Maintenance window not found"
+	}
+	return "Something went wrong. Please try again"
+}
+
+// validateMaintenanceWindow checks what the struct validations can not: the timezone, the recurrence and the range
+func validateMaintenanceWindow(window *models.MaintenanceWindow) []constants.Error {
+	_, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return []constants.Error{{Field: "timezone", Description: "timezone should be a valid IANA timezone eg. Europe/Berlin"}}
+	}
+
+	if window.Recurrence != "" {
+		_, err = schedule.Parse(window.Recurrence)
+		if err != nil {
+			return []constants.Error{{Field: "recurrence", Description: "recurrence should be a cron expression or an RRULE: " + err.Error()}}
+		}
+	}
+
+	if window.RepeatUntil != nil && window.RepeatUntil.Before(window.StartsAt) {
+		return []constants.Error{{Field: "repeat_until", Description: "repeat_until should be after starts_at"}}
+	}
+	return nil
+}
+
+// resolveMaintenanceWindowWebsites looks up the requested websites of the user, a website listed twice is covered once
+func (b *BaseController) resolveMaintenanceWindowWebsites(ctx *gin.Context, userID uint, websiteUUIDs []string) ([]models.MaintenanceWindowWebsite, int, error) {
+	var (
+		websiteRepo = models.InitWebsiteRepo(b.DB)
+		websites    = make([]models.MaintenanceWindowWebsite, 0, len(websiteUUIDs))
+		seen        = make(map[uint]bool, len(websiteUUIDs))
+	)
+
+	for _, websiteUUID := range websiteUUIDs {
+		website, err := websiteRepo.GetWithTx(&models.Website{UUID: websiteUUID, UserId: userID}, b.DB.WithContext(ctx))
+		if err == gorm.ErrRecordNotFound {
+			return nil, http.StatusNotFound, err
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+
+		if seen[website.ID] {
+			continue
+		}
+		seen[website.ID] = true
+		websites = append(websites, models.MaintenanceWindowWebsite{WebsiteId: website.ID})
+	}
+	return websites, http.StatusOK, nil
+}
+
+// getOwnedMaintenanceWindow fetches the maintenance window of the :uuid path param, scoped to the authenticated user
+func (b *BaseController) getOwnedMaintenanceWindow(ctx *gin.Context) (*models.MaintenanceWindow, int, error) {
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	window, err := models.InitMaintenanceWindowsRepo(b.DB).GetWithTx(b.DB.WithContext(ctx), &models.MaintenanceWindow{UUID: ctx.Param("uuid"), UserId: user.ID})
+	if err == gorm.ErrRecordNotFound {
+		return nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return window, http.StatusOK, nil
+}
+
+func (b *BaseController) CreateMaintenanceWindow(ctx *gin.Context) {
+	var (
+		request                CreateMaintenanceWindowRequest
+		maintenanceWindowsRepo = models.InitMaintenanceWindowsRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	if request.Timezone == "" {
+		request.Timezone = "UTC"
+	}
+	window := &models.MaintenanceWindow{
+		Name:            request.Name,
+		StartsAt:        request.StartsAt,
+		DurationMinutes: request.DurationMinutes,
+		Timezone:        request.Timezone,
+		Recurrence:      request.Recurrence,
+		RepeatUntil:     request.RepeatUntil,
+	}
+
+	validationErrors := b.ValidateRequest(&request)
+	if validationErrors == nil {
+		validationErrors = validateMaintenanceWindow(window)
+	}
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		logger.Error("error in getting user from context | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	window.UserId = user.ID
+
+	websites, code, err := b.resolveMaintenanceWindowWebsites(ctx, user.ID, request.WebsiteUUIDs)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+	window.Websites = websites
+
+	err = maintenanceWindowsRepo.CreateWithTx(b.DB.WithContext(ctx), window)
+	if err == nil {
+		//reloaded for the websites of the response
+		window, err = maintenanceWindowsRepo.GetWithTx(b.DB.WithContext(ctx), &models.MaintenanceWindow{ID: window.ID})
+	}
+	if err != nil {
+		logger.Error("error in creating maintenance window | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, MaintenanceWindowResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Maintenance window created successfully.",
+		Data:    window,
+	})
+}
+
+func (b *BaseController) ListMaintenanceWindows(ctx *gin.Context) {
+	var (
+		request                PaginationRequest
+		maintenanceWindowsRepo = models.InitMaintenanceWindowsRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListMaintenanceWindowsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		logger.Error("error in getting user from context | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListMaintenanceWindowsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	windows, total, err := maintenanceWindowsRepo.ListByUserID(ctx, user.ID, request.PageSize, request.offset())
+	if err != nil {
+		logger.Error("error in listing maintenance windows | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListMaintenanceWindowsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListMaintenanceWindowsResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Maintenance windows fetched successfully.",
+		Data:     windows,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
+
+func (b *BaseController) GetMaintenanceWindow(ctx *gin.Context) {
+	window, code, err := b.getOwnedMaintenanceWindow(ctx)
+	if err != nil {
+		logger.Error("error in fetching maintenance window | err: ", err)
+		ctx.AbortWithStatusJSON(code, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: maintenanceWindowErrorMessage(code),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MaintenanceWindowResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Maintenance window fetched successfully.",
+		Data:    window,
+	})
+}
+
+func (b *BaseController) UpdateMaintenanceWindow(ctx *gin.Context) {
+	var (
+		request                UpdateMaintenanceWindowRequest
+		maintenanceWindowsRepo = models.InitMaintenanceWindowsRepo(b.DB)
+		columns                []string
+	)
+
+	err := ctx.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	validationErrors := b.ValidateRequest(&request)
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	window, code, err := b.getOwnedMaintenanceWindow(ctx)
+	if err != nil {
+		logger.Error("error in fetching maintenance window | err: ", err)
+		ctx.AbortWithStatusJSON(code, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: maintenanceWindowErrorMessage(code),
+		})
+		return
+	}
+
+	//only the fields present in the request are selected, so empty values are written as well
+	if request.Name != nil {
+		window.Name = *request.Name
+		columns = append(columns, "name")
+	}
+	if request.StartsAt != nil {
+		window.StartsAt = *request.StartsAt
+		columns = append(columns, "starts_at")
+	}
+	if request.DurationMinutes != nil {
+		window.DurationMinutes = *request.DurationMinutes
+		columns = append(columns, "duration_minutes")
+	}
+	if request.Timezone != nil {
+		window.Timezone = *request.Timezone
+		columns = append(columns, "timezone")
+	}
+	if request.Recurrence != nil {
+		window.Recurrence = *request.Recurrence
+		columns = append(columns, "recurrence")
+	}
+	if request.RepeatUntil != nil {
+		window.RepeatUntil = request.RepeatUntil
+		if request.RepeatUntil.IsZero() {
+			window.RepeatUntil = nil
+		}
+		columns = append(columns, "repeat_until")
+	}
+
+	validationErrors = validateMaintenanceWindow(window)
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	var websites []models.MaintenanceWindowWebsite
+	if request.WebsiteUUIDs != nil {
+		websites, code, err = b.resolveMaintenanceWindowWebsites(ctx, window.UserId, *request.WebsiteUUIDs)
+		if err != nil {
+			logger.Error("error in fetching website | err: ", err)
+			ctx.AbortWithStatusJSON(code, MaintenanceWindowResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: websiteErrorMessage(code),
+			})
+			return
+		}
+	}
+
+	err = b.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(columns) != 0 {
+			err := maintenanceWindowsRepo.UpdateSelectedWithTx(tx, &models.MaintenanceWindow{ID: window.ID}, window, columns...)
+			if err != nil {
+				return err
+			}
+		}
+		if request.WebsiteUUIDs != nil {
+			return maintenanceWindowsRepo.ReplaceWebsitesWithTx(tx, window.ID, websites)
+		}
+		return nil
+	})
+	if err == nil {
+		window, err = maintenanceWindowsRepo.GetWithTx(b.DB.WithContext(ctx), &models.MaintenanceWindow{ID: window.ID})
+	}
+	if err != nil {
+		logger.Error("error in updating maintenance window | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MaintenanceWindowResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Maintenance window updated successfully.",
+		Data:    window,
+	})
+}
+
+func (b *BaseController) DeleteMaintenanceWindow(ctx *gin.Context) {
+	var (
+		maintenanceWindowsRepo = models.InitMaintenanceWindowsRepo(b.DB)
+	)
+
+	window, code, err := b.getOwnedMaintenanceWindow(ctx)
+	if err != nil {
+		logger.Error("error in fetching maintenance window | err: ", err)
+		ctx.AbortWithStatusJSON(code, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: maintenanceWindowErrorMessage(code),
+		})
+		return
+	}
+
+	err = maintenanceWindowsRepo.DeleteWithTx(b.DB.WithContext(ctx), &models.MaintenanceWindow{ID: window.ID})
+	if err != nil {
+		logger.Error("error in deleting maintenance window | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, MaintenanceWindowResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MaintenanceWindowResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Maintenance window deleted successfully.",
+	})
+}
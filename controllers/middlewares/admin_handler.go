@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+
+	controllers "github.com/ankur12345678/uptime-monitor/Controllers"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleAdmin only lets admin users through, it expects HandleAuth to have run before it
+func HandleAdmin(c *gin.Context) {
+	user, err := controllers.Ctrl.GetUserFromContext(c)
+	if err != nil {
+		logger.Error("error in fetching user | err: ", err)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error_message": "Please login again",
+		})
+		return
+	}
+
+	if !user.IsAdmin {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error_message": "You are not allowed to access this resource",
+		})
+		return
+	}
+	c.Next()
+}
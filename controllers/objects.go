@@ -1,5 +1,13 @@
 package controllers
 
+import (
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/statuspage"
+)
+
 type SignUpRequest struct {
 	FirstName string `json:"first_name,omitempty" validate:"required"`
 	LastName  string `json:"last_name,omitempty" validate:"required"`
@@ -13,12 +21,27 @@ type SignInRequest struct {
 }
 
 type RegisterWebsiteRequest struct {
-	WebsiteURL string `json:"website_url" validate:"required"`
+	WebsiteURL        string               `json:"website_url" validate:"required"` //the name of the job for heartbeats, of the flow for flows
+	IntervalSeconds   int                  `json:"interval_seconds,omitempty"`      //the expected period between pings for heartbeats
+	CheckType         models.CheckType     `json:"check_type,omitempty"`
+	DNSRecordType     models.DNSRecordType `json:"dns_record_type,omitempty"`
+	DNSExpectedValues []string             `json:"dns_expected_values,omitempty"`
+	HTTPMethod        string               `json:"http_method,omitempty"`
+	HTTPHeaders       map[string]string    `json:"http_headers,omitempty"`
+	HTTPBody          string               `json:"http_body,omitempty"`
+	AuthType          models.AuthType      `json:"auth_type,omitempty"`
+	AuthUsername      string               `json:"auth_username,omitempty"`
+	AuthSecret        string               `json:"auth_secret,omitempty"`
+
+	HeartbeatGraceSeconds int               `json:"heartbeat_grace_seconds,omitempty"`
+	FlowSteps             []models.FlowStep `json:"flow_steps,omitempty"`
 }
 
 type RegisterWebsiteResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
+	//the url heartbeats are pinged at
+	PingURL string `json:"ping_url,omitempty"`
 }
 
 type WebsiteLivelinessResponse struct {
@@ -26,13 +49,308 @@ type WebsiteLivelinessResponse struct {
 	Message string `json:"message"`
 }
 
+type PaginationRequest struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size"`
+}
+
+// normalize fills in defaults and caps the page size
+func (p *PaginationRequest) normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize < 1 {
+		p.PageSize = constants.DEFAULT_PAGE_SIZE
+	}
+	if p.PageSize > constants.MAX_PAGE_SIZE {
+		p.PageSize = constants.MAX_PAGE_SIZE
+	}
+}
+
+func (p *PaginationRequest) offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+type ListWebsitesRequest struct {
+	PaginationRequest
+	HealthStatus models.HealthStatus `form:"health_status"`
+}
+
+type ListWebsitesResponse struct {
+	Status   string                     `json:"status"`
+	Message  string                     `json:"message"`
+	Data     []models.WebsiteWithStatus `json:"data"`
+	Page     int                        `json:"page"`
+	PageSize int                        `json:"page_size"`
+	Total    int64                      `json:"total"`
+}
+
+type GetWebsiteResponse struct {
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Data    *models.WebsiteWithStatus `json:"data,omitempty"`
+}
+
+// UpdateWebsiteRequest is a partial update, only the fields present in the request are changed
+type UpdateWebsiteRequest struct {
+	WebsiteURL        *string               `json:"website_url,omitempty"`
+	IntervalSeconds   *int                  `json:"interval_seconds,omitempty"`
+	CheckType         *models.CheckType     `json:"check_type,omitempty"`
+	DNSRecordType     *models.DNSRecordType `json:"dns_record_type,omitempty"`
+	DNSExpectedValues *[]string             `json:"dns_expected_values,omitempty"`
+	HTTPMethod        *string               `json:"http_method,omitempty"`
+	HTTPHeaders       *map[string]string    `json:"http_headers,omitempty"`
+	HTTPBody          *string               `json:"http_body,omitempty"`
+	AuthType          *models.AuthType      `json:"auth_type,omitempty"`
+	AuthUsername      *string               `json:"auth_username,omitempty"`
+	AuthSecret        *string               `json:"auth_secret,omitempty"`
+
+	HeartbeatGraceSeconds *int               `json:"heartbeat_grace_seconds,omitempty"`
+	FlowSteps             *[]models.FlowStep `json:"flow_steps,omitempty"`
+}
+
+type UpdateWebsiteResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// UpdateAlertConfigRequest is a partial update, only the fields present in the request are changed
 type UpdateAlertConfigRequest struct {
-	WebsiteId        uint `json:"website_id,omitempty" validate:"required"`
-	IsEnabled        bool `json:"is_enabled,omitempty"`
-	FailureThreshold uint `json:"failure_threshold,omitempty"`
-	LatencyThreshold uint `json:"latency_threshold,omitempty"`
+	IsEnabled        *bool `json:"is_enabled,omitempty"`
+	FailureThreshold *int  `json:"failure_threshold,omitempty" validate:"omitempty,min=1,max=100"`
+	LatencyThreshold *int  `json:"latency_threshold,omitempty" validate:"omitempty,min=0,max=60000"`
+	//number of probe locations which have to agree on a failure, capped at the locations running
+	LocationQuorum *int `json:"location_quorum,omitempty" validate:"omitempty,min=1,max=20"`
+	//alerts once the certificate expires within these many days, 0 disables it
+	CertExpiryAlertDays *int `json:"cert_expiry_alert_days,omitempty" validate:"omitempty,min=0,max=365"`
+	//mutes notifications for the given minutes from now, 0 unmutes
+	MuteForMinutes *int `json:"mute_for_minutes,omitempty" validate:"omitempty,min=0,max=43200"`
+
+	Assertions *[]models.Assertion `json:"assertions,omitempty"`
+
+	//see models.EscalationPolicy, 0 disables escalation/reminders
+	EscalateAfterMinutes *int `json:"escalate_after_minutes,omitempty" validate:"omitempty,min=0,max=1440"`
+	RepeatEveryMinutes   *int `json:"repeat_every_minutes,omitempty" validate:"omitempty,min=0,max=1440"`
+}
+
+type AlertConfigResponse struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    *models.AlertConfig `json:"data,omitempty"`
+}
+
+type CreateAlertTargetRequest struct {
+	TargetType models.TargetType `json:"target_type" validate:"required,oneof=email sms voice slack discord teams webhook"`
+	//email address for email targets, E.164 number for sms/voice, https webhook url for the others
+	TargetValue string `json:"target_value" validate:"required,max=2048"`
+	//defaults to 1, see models.EscalationPolicy
+	EscalationLevel int `json:"escalation_level,omitempty" validate:"omitempty,min=1,max=2"`
+}
+
+type AlertTargetResponse struct {
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    *models.AlertTarget `json:"data,omitempty"`
+	//only returned once, when a generic webhook target is created
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+type ListAlertTargetsResponse struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message"`
+	Data    []models.AlertTarget `json:"data"`
+}
+
+type WebsiteStatsRequest struct {
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Bucket string    `form:"bucket"`
+}
+
+type WebsiteStats struct {
+	From        time.Time                `json:"from"`
+	To          time.Time                `json:"to"`
+	Bucket      string                   `json:"bucket"`
+	Summary     *models.LogStats         `json:"summary"`
+	StatusCodes []models.StatusCodeCount `json:"failed_status_codes"`
+	Series      []models.LogStatsBucket  `json:"series"`
+	//latency/uptime as seen from every probe location, to spot regional problems
+	Locations []models.LocationLogStats `json:"locations"`
 }
-type UpdateAlertConfigResponse struct {
+
+type WebsiteStatsResponse struct {
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Data    *WebsiteStats `json:"data,omitempty"`
+}
+
+type AcknowledgeIncidentResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
+
+type ListIncidentsResponse struct {
+	Status   string            `json:"status"`
+	Message  string            `json:"message"`
+	Data     []models.Incident `json:"data"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	Total    int64             `json:"total"`
+}
+
+type ListIncidentEventsRequest struct {
+	PaginationRequest
+	//defaults to FAILED and DEAD_LETTER
+	EventStatus models.EventStatus `form:"event_status"`
+}
+
+type ListIncidentEventsResponse struct {
+	Status   string                 `json:"status"`
+	Message  string                 `json:"message"`
+	Data     []models.IncidentEvent `json:"data"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+	Total    int64                  `json:"total"`
+}
+
+type IncidentEventResponse struct {
+	Status  string                `json:"status"`
+	Message string                `json:"message"`
+	Data    *models.IncidentEvent `json:"data,omitempty"`
+}
+
+type CreateMaintenanceWindowRequest struct {
+	//empty covers every website of the user
+	WebsiteUUIDs    []string  `json:"website_uuids,omitempty" validate:"omitempty,max=100,dive,required"`
+	Name            string    `json:"name" validate:"required,max=255"`
+	StartsAt        time.Time `json:"starts_at" validate:"required"`
+	DurationMinutes int       `json:"duration_minutes" validate:"required,min=1,max=10080"`
+	//IANA name, defaults to UTC
+	Timezone string `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	//cron expression ("0 2 * * SUN") or RRULE ("FREQ=WEEKLY;BYDAY=SU"), empty for a one-off window
+	Recurrence  string     `json:"recurrence,omitempty" validate:"omitempty,max=255"`
+	RepeatUntil *time.Time `json:"repeat_until,omitempty"`
+}
+
+// UpdateMaintenanceWindowRequest is a partial update, only the fields present in the request are changed
+type UpdateMaintenanceWindowRequest struct {
+	//replaces the websites of the window, an empty list covers every website of the user
+	WebsiteUUIDs    *[]string  `json:"website_uuids,omitempty" validate:"omitempty,max=100,dive,required"`
+	Name            *string    `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	DurationMinutes *int       `json:"duration_minutes,omitempty" validate:"omitempty,min=1,max=10080"`
+	Timezone        *string    `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	//an empty string turns the window into a one-off window
+	Recurrence *string `json:"recurrence,omitempty" validate:"omitempty,max=255"`
+	//the zero time repeats forever
+	RepeatUntil *time.Time `json:"repeat_until,omitempty"`
+}
+
+type MaintenanceWindowResponse struct {
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Data    *models.MaintenanceWindow `json:"data,omitempty"`
+}
+
+type ListMaintenanceWindowsResponse struct {
+	Status   string                     `json:"status"`
+	Message  string                     `json:"message"`
+	Data     []models.MaintenanceWindow `json:"data"`
+	Page     int                        `json:"page"`
+	PageSize int                        `json:"page_size"`
+	Total    int64                      `json:"total"`
+}
+
+type StatusPageWebsiteRequest struct {
+	WebsiteUUID string `json:"website_uuid" validate:"required"`
+	//defaults to the website url
+	DisplayName string `json:"display_name,omitempty" validate:"omitempty,max=255"`
+}
+
+type CreateStatusPageRequest struct {
+	Slug         string                     `json:"slug" validate:"required,min=3,max=63,slug"`
+	CustomDomain string                     `json:"custom_domain,omitempty" validate:"omitempty,max=253"`
+	Title        string                     `json:"title" validate:"required,max=255"`
+	Description  string                     `json:"description,omitempty" validate:"omitempty,max=1000"`
+	LogoURL      string                     `json:"logo_url,omitempty" validate:"omitempty,max=2048"`
+	AccentColor  string                     `json:"accent_color,omitempty"`
+	Websites     []StatusPageWebsiteRequest `json:"websites" validate:"required,min=1,max=50,dive"`
+}
+
+// UpdateStatusPageRequest is a partial update, only the fields present in the request are changed
+type UpdateStatusPageRequest struct {
+	Slug *string `json:"slug,omitempty" validate:"omitempty,min=3,max=63,slug"`
+	//an empty string removes the custom domain
+	CustomDomain *string `json:"custom_domain,omitempty" validate:"omitempty,max=253"`
+	Title        *string `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Description  *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	LogoURL      *string `json:"logo_url,omitempty" validate:"omitempty,max=2048"`
+	AccentColor  *string `json:"accent_color,omitempty"`
+	//replaces the websites of the page, in the given order
+	Websites []StatusPageWebsiteRequest `json:"websites,omitempty" validate:"omitempty,min=1,max=50,dive"`
+}
+
+type StatusPageResponse struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    *models.StatusPage `json:"data,omitempty"`
+}
+
+type ListStatusPagesResponse struct {
+	Status   string              `json:"status"`
+	Message  string              `json:"message"`
+	Data     []models.StatusPage `json:"data"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Total    int64               `json:"total"`
+}
+
+type PublicStatusPageResponse struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    *statuspage.Page `json:"data,omitempty"`
+}
+
+type CreateStatusPageSubscriberRequest struct {
+	Type models.SubscriberType `json:"type" validate:"required,oneof=email webhook"`
+	//an email address or an https webhook url
+	Target string `json:"target" validate:"required,max=2048"`
+}
+
+type StatusPageSubscriberResponse struct {
+	Status  string                       `json:"status"`
+	Message string                       `json:"message"`
+	Data    *models.StatusPageSubscriber `json:"data,omitempty"`
+}
+
+type ListStatusPageSubscribersResponse struct {
+	Status   string                        `json:"status"`
+	Message  string                        `json:"message"`
+	Data     []models.StatusPageSubscriber `json:"data"`
+	Page     int                           `json:"page"`
+	PageSize int                           `json:"page_size"`
+	Total    int64                         `json:"total"`
+}
+
+type PingResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type ListHeartbeatPingsResponse struct {
+	Status   string                 `json:"status"`
+	Message  string                 `json:"message"`
+	Data     []models.HeartbeatPing `json:"data"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+	Total    int64                  `json:"total"`
+}
+
+type ListStepLogsResponse struct {
+	Status   string           `json:"status"`
+	Message  string           `json:"message"`
+	Data     []models.StepLog `json:"data"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	Total    int64            `json:"total"`
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatsRange caps the queried range per bucket so that a series stays within a few thousand points
+var maxStatsRange = map[string]time.Duration{
+	"minute": 24 * time.Hour,
+	"hour":   31 * 24 * time.Hour,
+	"day":    366 * 24 * time.Hour,
+}
+
+func (b *BaseController) GetWebsiteStats(ctx *gin.Context) {
+	var (
+		request  WebsiteStatsRequest
+		logsRepo = models.InitLogsRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, WebsiteStatsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	//defaults to the hourly series of the last 24 hours
+	if request.To.IsZero() {
+		request.To = time.Now()
+	}
+	if request.From.IsZero() {
+		request.From = request.To.Add(-24 * time.Hour)
+	}
+	if request.Bucket == "" {
+		request.Bucket = "hour"
+	}
+
+	maxRange, ok := maxStatsRange[request.Bucket]
+	if !ok || !request.From.Before(request.To) || request.To.Sub(request.From) > maxRange {
+		logger.Error("invalid stats range/bucket")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, WebsiteStatsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter a valid range and bucket(minute upto 1 day, hour upto 31 days, day upto 366 days)",
+		})
+		return
+	}
+
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, WebsiteStatsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	summary, err := logsRepo.FetchStatsByWebsiteID(ctx, website.ID, request.From, request.To)
+	if err != nil {
+		logger.Error("error in fetching stats | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, WebsiteStatsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	statusCodes, err := logsRepo.FetchFailedStatusCodesByWebsiteID(ctx, website.ID, request.From, request.To)
+	if err != nil {
+		logger.Error("error in fetching status code breakdown | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, WebsiteStatsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	series, err := logsRepo.FetchStatsSeriesByWebsiteID(ctx, website.ID, request.From, request.To, request.Bucket)
+	if err != nil {
+		logger.Error("error in fetching stats series | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, WebsiteStatsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	locations, err := logsRepo.FetchStatsByLocation(ctx, website.ID, request.From, request.To)
+	if err != nil {
+		logger.Error("error in fetching stats by location | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, WebsiteStatsResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, WebsiteStatsResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Stats fetched successfully.",
+		Data: &WebsiteStats{
+			From:        request.From,
+			To:          request.To,
+			Bucket:      request.Bucket,
+			Summary:     summary,
+			StatusCodes: statusCodes,
+			Series:      series,
+			Locations:   locations,
+		},
+	})
+}
@@ -0,0 +1,715 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/statuspage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// on a custom domain the page is served at "/" and its json variant at statusPageDomainJSONPath
+const statusPageDomainJSONPath = "/status.json"
+
+func statusPageErrorMessage(code int) string {
+	if code == http.StatusNotFound {
+		return "Status page not found"
+	}
+	return "Something went wrong. Please try again"
+}
+
+func statusPageCacheKey(statusPageID uint) string {
+	return fmt.Sprintf("status-page:%d", statusPageID)
+}
+
+// normalizeHost lowercases the host and drops its port and trailing dot, so it can be compared with custom domains
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// apiHost is the host the api itself is served on, it is never treated as a custom domain
+func (b *BaseController) apiHost() string {
+	baseURL, err := url.Parse(b.Config.ServerBaseUrl)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(baseURL.Host)
+}
+
+// validateStatusPage checks what the struct validations can not: the branding and custom domain of a partial update
+// and the domain not being the api's own
+func (b *BaseController) validateStatusPage(page *models.StatusPage) []constants.Error {
+	if page.CustomDomain != nil {
+		if b.Validator.Var(*page.CustomDomain, "fqdn") != nil || *page.CustomDomain == b.apiHost() {
+			return []constants.Error{{Field: "custom_domain", Description: "custom_domain should be a domain name eg. status.example.com"}}
+		}
+	}
+	if page.LogoURL != "" && b.Validator.Var(page.LogoURL, "url") != nil {
+		return []constants.Error{{Field: "logo_url", Description: "logo_url should be a valid url"}}
+	}
+	if page.AccentColor != "" && b.Validator.Var(page.AccentColor, "hexcolor") != nil {
+		return []constants.Error{{Field: "accent_color", Description: "accent_color should be a hex color eg. #2f855a"}}
+	}
+	return nil
+}
+
+// statusPageTaken tells which of the slug and custom domain of the page is already used by another page, empty if none
+func (b *BaseController) statusPageTaken(ctx *gin.Context, page *models.StatusPage) (string, error) {
+	var (
+		statusPagesRepo = models.InitStatusPagesRepo(b.DB)
+		conditions      = map[string]*models.StatusPage{"slug": {Slug: page.Slug}}
+	)
+	if page.CustomDomain != nil {
+		conditions["custom_domain"] = &models.StatusPage{CustomDomain: page.CustomDomain}
+	}
+
+	for field, where := range conditions {
+		existing, err := statusPagesRepo.GetWithTx(b.DB.WithContext(ctx), where)
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if existing.ID != page.ID {
+			return field, nil
+		}
+	}
+	return "", nil
+}
+
+// resolveStatusPageWebsites looks up the requested websites of the user, in the requested order
+func (b *BaseController) resolveStatusPageWebsites(ctx *gin.Context, userID uint, requests []StatusPageWebsiteRequest) ([]models.StatusPageWebsite, int, error) {
+	var (
+		websiteRepo = models.InitWebsiteRepo(b.DB)
+		websites    = make([]models.StatusPageWebsite, 0, len(requests))
+	)
+
+	for i, request := range requests {
+		website, err := websiteRepo.GetWithTx(&models.Website{UUID: request.WebsiteUUID, UserId: userID}, b.DB.WithContext(ctx))
+		if err == gorm.ErrRecordNotFound {
+			return nil, http.StatusNotFound, err
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+
+		displayName := request.DisplayName
+		if displayName == "" {
+			displayName = website.WebsiteURL
+		}
+		websites = append(websites, models.StatusPageWebsite{
+			WebsiteId:   website.ID,
+			DisplayName: displayName,
+			Position:    i,
+		})
+	}
+	return websites, http.StatusOK, nil
+}
+
+// duplicateStatusPageWebsites rejects a website being listed twice on the same page
+func duplicateStatusPageWebsites(requests []StatusPageWebsiteRequest) []constants.Error {
+	seen := map[string]bool{}
+	for _, request := range requests {
+		if seen[request.WebsiteUUID] {
+			return []constants.Error{{Field: "websites", Description: "website " + request.WebsiteUUID + " is listed more than once"}}
+		}
+		seen[request.WebsiteUUID] = true
+	}
+	return nil
+}
+
+// getOwnedStatusPage fetches the status page of the :uuid path param, scoped to the authenticated user
+func (b *BaseController) getOwnedStatusPage(ctx *gin.Context) (*models.StatusPage, int, error) {
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	page, err := models.InitStatusPagesRepo(b.DB).GetWithTx(b.DB.WithContext(ctx), &models.StatusPage{UUID: ctx.Param("uuid"), UserId: user.ID})
+	if err == gorm.ErrRecordNotFound {
+		return nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return page, http.StatusOK, nil
+}
+
+func (b *BaseController) CreateStatusPage(ctx *gin.Context) {
+	var (
+		request         CreateStatusPageRequest
+		statusPagesRepo = models.InitStatusPagesRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	page := &models.StatusPage{
+		Slug:        request.Slug,
+		Title:       request.Title,
+		Description: request.Description,
+		LogoURL:     request.LogoURL,
+		AccentColor: request.AccentColor,
+	}
+	if request.CustomDomain != "" {
+		domain := normalizeHost(request.CustomDomain)
+		page.CustomDomain = &domain
+	}
+
+	validationErrors := b.ValidateRequest(&request)
+	if validationErrors == nil {
+		validationErrors = duplicateStatusPageWebsites(request.Websites)
+	}
+	if validationErrors == nil {
+		validationErrors = b.validateStatusPage(page)
+	}
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		logger.Error("error in getting user from context | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	page.UserId = user.ID
+
+	websites, code, err := b.resolveStatusPageWebsites(ctx, user.ID, request.Websites)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+	page.Websites = websites
+
+	taken, err := b.statusPageTaken(ctx, page)
+	if err != nil {
+		logger.Error("error in checking status page availability | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	if taken != "" {
+		ctx.AbortWithStatusJSON(http.StatusConflict, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: fmt.Sprintf("A status page with this %s already exists", taken),
+		})
+		return
+	}
+
+	err = statusPagesRepo.CreateWithTx(b.DB.WithContext(ctx), page)
+	if err == nil {
+		//reloaded for the websites details
+		page, err = statusPagesRepo.GetWithTx(b.DB.WithContext(ctx), &models.StatusPage{ID: page.ID})
+	}
+	if err != nil {
+		logger.Error("error in creating status page | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, StatusPageResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Status page created successfully.",
+		Data:    page,
+	})
+}
+
+func (b *BaseController) ListStatusPages(ctx *gin.Context) {
+	var (
+		request         PaginationRequest
+		statusPagesRepo = models.InitStatusPagesRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListStatusPagesResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		logger.Error("error in getting user from context | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListStatusPagesResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	pages, total, err := statusPagesRepo.ListByUserID(ctx, user.ID, request.PageSize, request.offset())
+	if err != nil {
+		logger.Error("error in listing status pages | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListStatusPagesResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListStatusPagesResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Status pages fetched successfully.",
+		Data:     pages,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
+
+func (b *BaseController) GetStatusPage(ctx *gin.Context) {
+	page, code, err := b.getOwnedStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.AbortWithStatusJSON(code, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: statusPageErrorMessage(code),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, StatusPageResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Status page fetched successfully.",
+		Data:    page,
+	})
+}
+
+func (b *BaseController) UpdateStatusPage(ctx *gin.Context) {
+	var (
+		request         UpdateStatusPageRequest
+		statusPagesRepo = models.InitStatusPagesRepo(b.DB)
+		columns         []string
+	)
+
+	err := ctx.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	validationErrors := b.ValidateRequest(&request)
+	if validationErrors == nil {
+		validationErrors = duplicateStatusPageWebsites(request.Websites)
+	}
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	page, code, err := b.getOwnedStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.AbortWithStatusJSON(code, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: statusPageErrorMessage(code),
+		})
+		return
+	}
+
+	//only the fields present in the request are selected, so empty values are written as well
+	if request.Slug != nil {
+		page.Slug = *request.Slug
+		columns = append(columns, "slug")
+	}
+	if request.CustomDomain != nil {
+		page.CustomDomain = nil
+		if domain := normalizeHost(*request.CustomDomain); domain != "" {
+			page.CustomDomain = &domain
+		}
+		columns = append(columns, "custom_domain")
+	}
+	if request.Title != nil {
+		page.Title = *request.Title
+		columns = append(columns, "title")
+	}
+	if request.Description != nil {
+		page.Description = *request.Description
+		columns = append(columns, "description")
+	}
+	if request.LogoURL != nil {
+		page.LogoURL = *request.LogoURL
+		columns = append(columns, "logo_url")
+	}
+	if request.AccentColor != nil {
+		page.AccentColor = *request.AccentColor
+		columns = append(columns, "accent_color")
+	}
+
+	validationErrors = b.validateStatusPage(page)
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	if request.Websites != nil {
+		websites, code, err := b.resolveStatusPageWebsites(ctx, page.UserId, request.Websites)
+		if err != nil {
+			logger.Error("error in fetching website | err: ", err)
+			ctx.AbortWithStatusJSON(code, StatusPageResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: websiteErrorMessage(code),
+			})
+			return
+		}
+		page.Websites = websites
+	}
+
+	taken, err := b.statusPageTaken(ctx, page)
+	if err != nil {
+		logger.Error("error in checking status page availability | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	if taken != "" {
+		ctx.AbortWithStatusJSON(http.StatusConflict, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: fmt.Sprintf("A status page with this %s already exists", taken),
+		})
+		return
+	}
+
+	err = b.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(columns) != 0 {
+			err := statusPagesRepo.UpdateSelectedWithTx(tx, &models.StatusPage{ID: page.ID}, page, columns...)
+			if err != nil {
+				return err
+			}
+		}
+		if request.Websites != nil {
+			err := statusPagesRepo.ReplaceWebsitesWithTx(tx, page.ID, page.Websites)
+			if err != nil {
+				return err
+			}
+		}
+
+		//reloaded for the websites details
+		page, err = statusPagesRepo.GetWithTx(tx, &models.StatusPage{ID: page.ID})
+		return err
+	})
+	if err != nil {
+		logger.Error("error in updating status page | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	b.RedisClient.Del(ctx, statusPageCacheKey(page.ID))
+
+	ctx.JSON(http.StatusOK, StatusPageResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Status page updated successfully.",
+		Data:    page,
+	})
+}
+
+func (b *BaseController) DeleteStatusPage(ctx *gin.Context) {
+	var (
+		statusPagesRepo = models.InitStatusPagesRepo(b.DB)
+	)
+
+	page, code, err := b.getOwnedStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.AbortWithStatusJSON(code, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: statusPageErrorMessage(code),
+		})
+		return
+	}
+
+	err = statusPagesRepo.DeleteWithTx(b.DB.WithContext(ctx), &models.StatusPage{ID: page.ID})
+	if err != nil {
+		logger.Error("error in deleting status page | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	b.RedisClient.Del(ctx, statusPageCacheKey(page.ID))
+
+	ctx.JSON(http.StatusOK, StatusPageResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Status page deleted successfully.",
+	})
+}
+
+// buildPublicStatusPage summarises the websites of the page: their current status, daily uptime over the
+// history and their recent incidents. Display names stand in for the websites, urls are never shown.
+func (b *BaseController) buildPublicStatusPage(ctx *gin.Context, page *models.StatusPage) (*statuspage.Page, error) {
+	var (
+		logsRepo      = models.InitLogsRepo(b.DB)
+		incidentsRepo = models.InitIncidentsRepo(b.DB)
+		now           = time.Now().UTC()
+		today         = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		from          = today.AddDate(0, 0, 1-constants.STATUS_PAGE_HISTORY_DAYS)
+		websiteIDs    = make([]uint, 0, len(page.Websites))
+		names         = map[uint]string{}
+		down          = map[uint]bool{}
+		days          = map[uint]map[string]models.DailyUptime{}
+	)
+
+	for _, website := range page.Websites {
+		websiteIDs = append(websiteIDs, website.WebsiteId)
+		names[website.WebsiteId] = website.DisplayName
+		days[website.WebsiteId] = map[string]models.DailyUptime{}
+	}
+
+	uptime, err := logsRepo.FetchDailyUptimeByWebsiteIDs(ctx, websiteIDs, from)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range uptime {
+		days[day.WebsiteId][day.Day.Format("2006-01-02")] = day
+	}
+
+	incidents, err := incidentsRepo.ListRecentByWebsiteIDs(ctx, websiteIDs, now.AddDate(0, 0, -constants.STATUS_PAGE_INCIDENT_DAYS), constants.STATUS_PAGE_INCIDENT_LIMIT)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &statuspage.Page{
+		Title:       page.Title,
+		Description: page.Description,
+		LogoURL:     page.LogoURL,
+		AccentColor: page.AccentColor,
+		Websites:    []statuspage.Website{},
+		Incidents:   []statuspage.Incident{},
+		GeneratedAt: now,
+	}
+	if summary.AccentColor == "" {
+		summary.AccentColor = statuspage.DefaultAccentColor
+	}
+
+	for _, incident := range incidents {
+		if incident.State != models.IncidentStateResolved {
+			down[incident.WebsiteId] = true
+		}
+		summary.Incidents = append(summary.Incidents, statuspage.Incident{
+			Website:           names[incident.WebsiteId],
+			State:             string(incident.State),
+			StartedAt:         incident.StartedAt,
+			ResolvedAt:        incident.ResolvedAt,
+			DurationInSeconds: incident.DurationInSeconds,
+		})
+	}
+
+	for _, websiteID := range websiteIDs {
+		var (
+			website = statuspage.Website{Name: names[websiteID], Status: statuspage.StatusOperational, UptimePercentage: 100}
+			total   int64
+			healthy int64
+		)
+		if down[websiteID] {
+			website.Status = statuspage.StatusOutage
+		}
+
+		for date := from; !date.After(today); date = date.AddDate(0, 0, 1) {
+			day := days[websiteID][date.Format("2006-01-02")]
+			total += day.TotalChecks
+			healthy += day.HealthyChecks
+			website.Days = append(website.Days, statuspage.Day{
+				Date:             date.Format("2006-01-02"),
+				TotalChecks:      day.TotalChecks,
+				UptimePercentage: day.UptimePercentage,
+				Status:           statuspage.DayStatus(day.TotalChecks, day.UptimePercentage),
+			})
+		}
+		if total != 0 {
+			website.UptimePercentage = 100 * float64(healthy) / float64(total)
+		}
+		summary.Websites = append(summary.Websites, website)
+	}
+	summary.Status = statuspage.OverallStatus(summary.Websites)
+
+	return summary, nil
+}
+
+// publicStatusPage serves the summary from the cache when present, status pages are public and may get
+// a lot of traffic while something is down
+func (b *BaseController) publicStatusPage(ctx *gin.Context, page *models.StatusPage) (*statuspage.Page, error) {
+	var (
+		summary statuspage.Page
+		key     = statusPageCacheKey(page.ID)
+	)
+
+	cached, err := b.RedisClient.Get(ctx, key).Bytes()
+	if err == nil && json.Unmarshal(cached, &summary) == nil {
+		return &summary, nil
+	}
+
+	built, err := b.buildPublicStatusPage(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(built)
+	if err == nil {
+		err = b.RedisClient.Set(ctx, key, encoded, constants.STATUS_PAGE_CACHE_TTL_SECONDS*time.Second).Err()
+	}
+	if err != nil {
+		logger.Error("error in caching status page | err: ", err)
+	}
+	return built, nil
+}
+
+func (b *BaseController) writePublicStatusPageJSON(ctx *gin.Context, page *models.StatusPage) {
+	summary, err := b.publicStatusPage(ctx, page)
+	if err != nil {
+		logger.Error("error in building status page | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, PublicStatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PublicStatusPageResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Status page fetched successfully.",
+		Data:    summary,
+	})
+}
+
+func (b *BaseController) writePublicStatusPageHTML(ctx *gin.Context, page *models.StatusPage) {
+	var body bytes.Buffer
+
+	summary, err := b.publicStatusPage(ctx, page)
+	if err == nil {
+		err = statuspage.Render(&body, summary)
+	}
+	if err != nil {
+		logger.Error("error in rendering status page | err: ", err)
+		ctx.String(http.StatusInternalServerError, "Something went wrong. Please try again")
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+}
+
+// getPublicStatusPage fetches the status page of the :slug path param, no authentication needed
+func (b *BaseController) getPublicStatusPage(ctx *gin.Context) (*models.StatusPage, int, error) {
+	page, err := models.InitStatusPagesRepo(b.DB).GetWithTx(b.DB.WithContext(ctx), &models.StatusPage{Slug: ctx.Param("slug")})
+	if err == gorm.ErrRecordNotFound {
+		return nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return page, http.StatusOK, nil
+}
+
+func (b *BaseController) GetPublicStatusPage(ctx *gin.Context) {
+	page, code, err := b.getPublicStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.AbortWithStatusJSON(code, PublicStatusPageResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: statusPageErrorMessage(code),
+		})
+		return
+	}
+
+	b.writePublicStatusPageJSON(ctx, page)
+}
+
+func (b *BaseController) RenderPublicStatusPage(ctx *gin.Context) {
+	page, code, err := b.getPublicStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.String(code, statusPageErrorMessage(code))
+		return
+	}
+
+	b.writePublicStatusPageHTML(ctx, page)
+}
+
+// ServeStatusPageDomain serves status pages on their custom domain, "/" as html and statusPageDomainJSONPath
+// as json. Requests for the api host or for any other path are passed on.
+func (b *BaseController) ServeStatusPageDomain(ctx *gin.Context) {
+	path := ctx.Request.URL.Path
+	if ctx.Request.Method != http.MethodGet || (path != "/" && path != statusPageDomainJSONPath) {
+		ctx.Next()
+		return
+	}
+
+	host := normalizeHost(ctx.Request.Host)
+	if host == "" || host == b.apiHost() {
+		ctx.Next()
+		return
+	}
+
+	page, err := models.InitStatusPagesRepo(b.DB).GetWithTx(b.DB.WithContext(ctx), &models.StatusPage{CustomDomain: &host})
+	if err == gorm.ErrRecordNotFound {
+		ctx.Next()
+		return
+	}
+	if err != nil {
+		logger.Error("error in fetching status page by domain | err: ", err)
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Abort()
+	if path == statusPageDomainJSONPath {
+		b.writePublicStatusPageJSON(ctx, page)
+		return
+	}
+	b.writePublicStatusPageHTML(ctx, page)
+}
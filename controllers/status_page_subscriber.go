@@ -0,0 +1,437 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/sendgrid"
+	"github.com/ankur12345678/uptime-monitor/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const subscriberConfirmationTimeout = 10 * time.Second
+
+// the same response is given whether or not the target was subscribed already, so the
+// subscribers of a page can not be probed through this endpoint
+const subscribeResponseMessage = "Please confirm the subscription using the link sent to you."
+
+func subscribeRateLimitKey(clientIP string) string {
+	return "status-page-subscribe:" + clientIP
+}
+
+// isValidSubscriberTarget checks the target against what the subscriber type delivers to
+func (b *BaseController) isValidSubscriberTarget(subscriberType models.SubscriberType, target string) bool {
+	if subscriberType == models.SubscriberTypeEmail {
+		return b.Validator.Var(target, "email") == nil
+	}
+	return b.Validator.Var(target, "url") == nil && strings.HasPrefix(target, "https://")
+}
+
+// subscribeRateLimited counts the subscribe request of the client and tells if it went over the limit of the window
+func (b *BaseController) subscribeRateLimited(ctx *gin.Context) (bool, error) {
+	key := subscribeRateLimitKey(ctx.ClientIP())
+	count, err := b.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if count == 1 {
+		err = b.RedisClient.Expire(ctx, key, constants.STATUS_PAGE_SUBSCRIBE_RATE_LIMIT_WINDOW_MINUTES*time.Minute).Err()
+		if err != nil {
+			return false, err
+		}
+	}
+	return count > constants.STATUS_PAGE_SUBSCRIBE_RATE_LIMIT, nil
+}
+
+// sendSubscriberConfirmation mails the confirmation link to email subscribers and posts it to webhook subscribers
+func (b *BaseController) sendSubscriberConfirmation(ctx *gin.Context, page *models.StatusPage, subscriber *models.StatusPageSubscriber) error {
+	var (
+		pageURL    = page.PublicURL(b.Config.ServerBaseUrl)
+		confirmURL = fmt.Sprintf("%s/v1/status-subscribers/confirm/%s", b.Config.ServerBaseUrl, subscriber.ConfirmationToken)
+	)
+
+	if subscriber.Type == models.SubscriberTypeEmail {
+		return sendgrid.SendSubscriptionConfirmationEmail(subscriber.Target, b.Config, sendgrid.SubscriptionEmailData{
+			PageTitle: page.Title,
+			PageURL:   pageURL,
+			Link:      confirmURL,
+			Year:      time.Now().Year(),
+		})
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"event":           "subscription_confirmation",
+		"status_page":     page.Title,
+		"status_page_url": pageURL,
+		"confirm_url":     confirmURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscriber.Target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	//the url is given by an anonymous user, it must not reach the internal network
+	resp, err := utils.NewPublicHTTPClient(subscriberConfirmationTimeout).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SubscribeToStatusPage is the public subscribe form of a status page. The subscriber only gets updates
+// once the link sent to the target is opened.
+func (b *BaseController) SubscribeToStatusPage(ctx *gin.Context) {
+	var (
+		request         CreateStatusPageSubscriberRequest
+		subscribersRepo = models.InitStatusPageSubscribersRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	request.Target = strings.TrimSpace(request.Target)
+	if request.Type == models.SubscriberTypeEmail {
+		request.Target = strings.ToLower(request.Target)
+	}
+
+	validationErrors := b.ValidateRequest(&request)
+	if validationErrors == nil && !b.isValidSubscriberTarget(request.Type, request.Target) {
+		validationErrors = []constants.Error{{Field: "target", Description: "target should be an email address or an https url"}}
+	}
+	if validationErrors != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": validationErrors,
+		})
+		return
+	}
+
+	limited, err := b.subscribeRateLimited(ctx)
+	if err != nil {
+		logger.Error("error in rate limiting subscribe request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	if limited {
+		ctx.AbortWithStatusJSON(http.StatusTooManyRequests, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Too many requests. Please try again later",
+		})
+		return
+	}
+
+	page, code, err := b.getPublicStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.AbortWithStatusJSON(code, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: statusPageErrorMessage(code),
+		})
+		return
+	}
+
+	subscriber, err := subscribersRepo.GetWithTx(b.DB.WithContext(ctx), &models.StatusPageSubscriber{
+		StatusPageId: page.ID,
+		Type:         request.Type,
+		Target:       request.Target,
+	})
+	if err != nil && err != gorm.ErrRecordNotFound {
+		logger.Error("error in fetching status page subscriber | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	exists := err == nil
+
+	//confirmed subscribers and recently sent confirmations are left alone, the target can not be flooded with links
+	if exists && (subscriber.ConfirmedAt != nil || (subscriber.ConfirmationSentAt != nil &&
+		time.Since(*subscriber.ConfirmationSentAt) < constants.STATUS_PAGE_SUBSCRIBER_RESEND_MINUTES*time.Minute)) {
+		ctx.JSON(http.StatusOK, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_SUCCESS_RESPONSE,
+			Message: subscribeResponseMessage,
+		})
+		return
+	}
+
+	token, err := utils.GenerateToken()
+	if err == nil && !exists {
+		subscriber = &models.StatusPageSubscriber{StatusPageId: page.ID, Type: request.Type, Target: request.Target}
+		subscriber.UnsubscribeToken, err = utils.GenerateToken()
+	}
+	if err != nil {
+		logger.Error("error in generating subscriber tokens | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	var (
+		now      = time.Now()
+		previous = *subscriber
+	)
+	subscriber.ConfirmationToken = token
+	subscriber.ConfirmationSentAt = &now
+
+	if exists {
+		err = subscribersRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.StatusPageSubscriber{ID: subscriber.ID}, subscriber,
+			"confirmation_token", "confirmation_sent_at")
+	} else {
+		err = subscribersRepo.CreateWithTx(b.DB.WithContext(ctx), subscriber)
+	}
+	if err == nil {
+		//sent outside of any transaction, the target is given by an anonymous user and may be slow
+		err = b.sendSubscriberConfirmation(ctx, page, subscriber)
+		if err != nil {
+			b.revertSubscription(ctx, &previous, exists)
+		}
+	}
+	if err != nil {
+		logger.Error("error in subscribing to status page | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, StatusPageSubscriberResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: subscribeResponseMessage,
+	})
+}
+
+// revertSubscription undoes a subscription whose confirmation could not be sent: a new subscriber is deleted
+// and an existing one gets its previous confirmation back, so that subscribing again is not held off
+func (b *BaseController) revertSubscription(ctx *gin.Context, previous *models.StatusPageSubscriber, exists bool) {
+	var (
+		subscribersRepo = models.InitStatusPageSubscribersRepo(b.DB)
+		err             error
+	)
+
+	if exists {
+		err = subscribersRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.StatusPageSubscriber{ID: previous.ID}, previous,
+			"confirmation_token", "confirmation_sent_at")
+	} else {
+		err = subscribersRepo.DeleteWithTx(b.DB.WithContext(ctx), &models.StatusPageSubscriber{ID: previous.ID})
+	}
+	if err != nil {
+		logger.Error("error in reverting status page subscription | err: ", err)
+	}
+}
+
+// ConfirmStatusPageSubscriber is opened from the confirmation link, the token itself authenticates the request
+func (b *BaseController) ConfirmStatusPageSubscriber(ctx *gin.Context) {
+	var (
+		subscribersRepo = models.InitStatusPageSubscribersRepo(b.DB)
+		token           = ctx.Param("token")
+	)
+
+	//an empty token would match every subscriber as zero values are ignored in the where clause
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "The link is invalid or has expired",
+		})
+		return
+	}
+
+	subscriber, err := subscribersRepo.GetWithTx(b.DB.WithContext(ctx), &models.StatusPageSubscriber{ConfirmationToken: token})
+	if err != nil && err != gorm.ErrRecordNotFound {
+		logger.Error("error in fetching status page subscriber | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	expired := err == nil && subscriber.ConfirmationSentAt != nil &&
+		time.Since(*subscriber.ConfirmationSentAt) > constants.STATUS_PAGE_SUBSCRIBER_CONFIRMATION_TTL_HOURS*time.Hour
+	if err == gorm.ErrRecordNotFound || expired {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "The link is invalid or has expired",
+		})
+		return
+	}
+
+	now := time.Now()
+	err = subscribersRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.StatusPageSubscriber{ID: subscriber.ID},
+		&models.StatusPageSubscriber{ConfirmedAt: &now, ConfirmationToken: ""},
+		"confirmed_at", "confirmation_token")
+	if err != nil {
+		logger.Error("error in confirming status page subscriber | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, StatusPageSubscriberResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Subscription confirmed. You will now receive updates of the status page.",
+	})
+}
+
+// UnsubscribeStatusPageSubscriber is opened from the link in every update, it also takes the one-click
+// POST of mail clients honouring the List-Unsubscribe-Post header
+func (b *BaseController) UnsubscribeStatusPageSubscriber(ctx *gin.Context) {
+	var (
+		subscribersRepo = models.InitStatusPageSubscribersRepo(b.DB)
+		token           = ctx.Param("token")
+	)
+
+	//an empty token would match every subscriber as zero values are ignored in the where clause
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "The link is invalid",
+		})
+		return
+	}
+
+	err := subscribersRepo.DeleteWithTx(b.DB.WithContext(ctx), &models.StatusPageSubscriber{UnsubscribeToken: token})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	//unsubscribing twice is not an error, the first request may have been a link preview
+	ctx.JSON(http.StatusOK, StatusPageSubscriberResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "You have been unsubscribed.",
+	})
+}
+
+func (b *BaseController) ListStatusPageSubscribers(ctx *gin.Context) {
+	var (
+		request         PaginationRequest
+		subscribersRepo = models.InitStatusPageSubscribersRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListStatusPageSubscribersResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	page, code, err := b.getOwnedStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.AbortWithStatusJSON(code, ListStatusPageSubscribersResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: statusPageErrorMessage(code),
+		})
+		return
+	}
+
+	subscribers, total, err := subscribersRepo.ListByStatusPageID(ctx, page.ID, request.PageSize, request.offset())
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListStatusPageSubscribersResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListStatusPageSubscribersResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Status page subscribers fetched successfully.",
+		Data:     subscribers,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
+
+func (b *BaseController) DeleteStatusPageSubscriber(ctx *gin.Context) {
+	var (
+		subscribersRepo = models.InitStatusPageSubscribersRepo(b.DB)
+	)
+
+	page, code, err := b.getOwnedStatusPage(ctx)
+	if err != nil {
+		logger.Error("error in fetching status page | err: ", err)
+		ctx.AbortWithStatusJSON(code, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: statusPageErrorMessage(code),
+		})
+		return
+	}
+
+	subscriberID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || subscriberID == 0 {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Subscriber not found",
+		})
+		return
+	}
+
+	where := &models.StatusPageSubscriber{ID: uint(subscriberID), StatusPageId: page.ID}
+	_, err = subscribersRepo.GetWithTx(b.DB.WithContext(ctx), where)
+	if err == gorm.ErrRecordNotFound {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Subscriber not found",
+		})
+		return
+	}
+	if err == nil {
+		err = subscribersRepo.DeleteWithTx(b.DB.WithContext(ctx), where)
+	}
+	if err != nil {
+		logger.Error("error in deleting status page subscriber | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, StatusPageSubscriberResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, StatusPageSubscriberResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Subscriber removed successfully.",
+	})
+}
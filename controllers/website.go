@@ -1,13 +1,18 @@
 package controllers
 
 import (
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	models "github.com/ankur12345678/uptime-monitor/Models"
 	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/flow"
 	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func (b *BaseController) RegisterWebsite(ctx *gin.Context) {
@@ -28,7 +33,7 @@ func (b *BaseController) RegisterWebsite(ctx *gin.Context) {
 		return
 	}
 
-	if request.WebsiteURL == "" {
+	if request.WebsiteURL == "" || !isValidCheckConfig(request) || !isValidInterval(request.CheckType, request.IntervalSeconds) {
 		logger.Error("invalid request")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, RegisterWebsiteResponse{
 			Status:  constants.GENERIC_FAILURE_RESPONSE,
@@ -37,6 +42,29 @@ func (b *BaseController) RegisterWebsite(ctx *gin.Context) {
 		return
 	}
 
+	if request.CheckType == "" {
+		request.CheckType = models.CheckTypeHTTP
+	}
+	if request.HTTPMethod == "" {
+		request.HTTPMethod = http.MethodGet
+	}
+	if request.AuthType == "" {
+		request.AuthType = models.AuthTypeNone
+	}
+
+	var encryptedAuthSecret string
+	if request.AuthSecret != "" {
+		encryptedAuthSecret, err = utils.EncryptString(b.Config.EncryptionKey, request.AuthSecret)
+		if err != nil {
+			logger.Error("error in encrypting auth secret | err: ", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, RegisterWebsiteResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "Something went wrong. Please try again",
+			})
+			return
+		}
+	}
+
 	email, err := GetEmailFromContext(ctx)
 	if err != nil {
 		logger.Error("error in getting email from context | err: ", err)
@@ -59,7 +87,45 @@ func (b *BaseController) RegisterWebsite(ctx *gin.Context) {
 
 	tx := b.DB.Begin()
 
-	website := &models.Website{WebsiteURL: request.WebsiteURL, UserId: user.ID}
+	website := &models.Website{
+		WebsiteURL:        request.WebsiteURL,
+		UserId:            user.ID,
+		IntervalSeconds:   request.IntervalSeconds,
+		CheckType:         request.CheckType,
+		DNSRecordType:     request.DNSRecordType,
+		DNSExpectedValues: request.DNSExpectedValues,
+
+		HTTPMethod:          strings.ToUpper(request.HTTPMethod),
+		AuthType:            request.AuthType,
+		AuthUsername:        request.AuthUsername,
+		EncryptedAuthSecret: encryptedAuthSecret,
+
+		HeartbeatGraceSeconds: request.HeartbeatGraceSeconds,
+		FlowSteps:             request.FlowSteps,
+	}
+	err = website.SetHTTPRequestSpec(b.Config.EncryptionKey, request.HTTPHeaders, request.HTTPBody)
+	if err != nil {
+		logger.Error("error in encrypting request spec | err: ", err)
+		tx.Rollback()
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, RegisterWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	if website.CheckType == models.CheckTypeHeartbeat {
+		err = scheduleHeartbeat(website, time.Now())
+		if err != nil {
+			logger.Error("error in generating heartbeat token | err: ", err)
+			tx.Rollback()
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, RegisterWebsiteResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "Something went wrong. Please try again",
+			})
+			return
+		}
+	}
+
 	err = websiteRepo.Create(website)
 	if err != nil {
 		logger.Error("error in registering website | err: ", err)
@@ -96,9 +162,97 @@ func (b *BaseController) RegisterWebsite(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, RegisterWebsiteResponse{
 		Status:  constants.GENERIC_SUCCESS_RESPONSE,
 		Message: "Website registered successfully.",
+		PingURL: website.PingURL(b.Config.ServerBaseUrl),
 	})
 }
 
+// isValidInterval allows zero which falls back to the default interval, heartbeats have to give their period
+func isValidInterval(checkType models.CheckType, intervalSeconds int) bool {
+	if checkType == models.CheckTypeHeartbeat {
+		return intervalSeconds >= constants.MIN_HEARTBEAT_PERIOD_SECONDS && intervalSeconds <= constants.MAX_HEARTBEAT_PERIOD_SECONDS
+	}
+	return intervalSeconds == 0 || (intervalSeconds >= constants.MIN_CHECK_INTERVAL_SECONDS && intervalSeconds <= constants.MAX_CHECK_INTERVAL_SECONDS)
+}
+
+func isValidCheckConfig(request RegisterWebsiteRequest) bool {
+	if request.CheckType != models.CheckTypeHeartbeat && request.HeartbeatGraceSeconds != 0 {
+		return false
+	}
+	if request.CheckType != models.CheckTypeFlow && len(request.FlowSteps) != 0 {
+		return false
+	}
+
+	switch request.CheckType {
+	case "", models.CheckTypeHTTP:
+		return isValidHTTPRequestSpec(request) && request.DNSRecordType == "" && len(request.DNSExpectedValues) == 0
+	case models.CheckTypeTCP, models.CheckTypeTLS:
+		return !hasHTTPRequestSpec(request) && request.DNSRecordType == "" && len(request.DNSExpectedValues) == 0
+	case models.CheckTypeDNS:
+		if hasHTTPRequestSpec(request) {
+			return false
+		}
+		switch request.DNSRecordType {
+		case "", models.DNSRecordTypeA, models.DNSRecordTypeAAAA, models.DNSRecordTypeCNAME:
+			return true
+		}
+	case models.CheckTypeHeartbeat:
+		//zero falls back to the default grace time
+		return !hasHTTPRequestSpec(request) && request.DNSRecordType == "" && len(request.DNSExpectedValues) == 0 &&
+			(request.HeartbeatGraceSeconds == 0 || (request.HeartbeatGraceSeconds >= constants.MIN_HEARTBEAT_GRACE_SECONDS &&
+				request.HeartbeatGraceSeconds <= constants.MAX_HEARTBEAT_GRACE_SECONDS))
+	case models.CheckTypeFlow:
+		//the steps carry their own requests, the credentials are only used through their variables
+		if request.HTTPMethod != "" || len(request.HTTPHeaders) != 0 || request.HTTPBody != "" ||
+			(request.AuthType != "" && request.AuthType != models.AuthTypeNone) {
+			return false
+		}
+		return request.DNSRecordType == "" && len(request.DNSExpectedValues) == 0 && flow.Validate(request.FlowSteps) == nil
+	}
+	return false
+}
+
+// scheduleHeartbeat gives the website a ping token unless it has one and expects its first ping a period plus
+// the grace time from now, the sweep looks at it once that passes
+func scheduleHeartbeat(website *models.Website, now time.Time) error {
+	if website.HeartbeatToken == nil {
+		token, err := utils.GenerateToken()
+		if err != nil {
+			return err
+		}
+		website.HeartbeatToken = &token
+	}
+	if website.HeartbeatGraceSeconds == 0 {
+		website.HeartbeatGraceSeconds = constants.DEFAULT_HEARTBEAT_GRACE_SECONDS
+	}
+
+	pingDueAt := now.Add(website.HeartbeatPeriod())
+	website.PingDueAt = &pingDueAt
+	website.NextCheckAt = pingDueAt
+	return nil
+}
+
+func hasHTTPRequestSpec(request RegisterWebsiteRequest) bool {
+	return request.HTTPMethod != "" || len(request.HTTPHeaders) != 0 || request.HTTPBody != "" || request.AuthType != "" || request.AuthUsername != "" || request.AuthSecret != ""
+}
+
+func isValidHTTPRequestSpec(request RegisterWebsiteRequest) bool {
+	switch strings.ToUpper(request.HTTPMethod) {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return false
+	}
+
+	switch request.AuthType {
+	case "", models.AuthTypeNone:
+		return request.AuthUsername == "" && request.AuthSecret == ""
+	case models.AuthTypeBasic:
+		return request.AuthUsername != "" && request.AuthSecret != ""
+	case models.AuthTypeBearer:
+		return request.AuthSecret != ""
+	}
+	return false
+}
+
 func (b *BaseController) TestWebsiteLiveliness(ctx *gin.Context) {
 	var (
 		request RegisterWebsiteRequest
@@ -114,7 +268,20 @@ func (b *BaseController) TestWebsiteLiveliness(ctx *gin.Context) {
 		return
 	}
 
-	if request.WebsiteURL == "" {
+	if request.CheckType == "" {
+		request.CheckType = models.CheckTypeHTTP
+	}
+	//the other check types are not a single http request, a tcp/dns/tls monitor would always be reported down
+	if request.CheckType != models.CheckTypeHTTP {
+		logger.Error("invalid request")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, WebsiteLivelinessResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Liveliness can only be tested for http monitors",
+		})
+		return
+	}
+
+	if request.WebsiteURL == "" || !isValidCheckConfig(request) {
 		logger.Error("invalid request")
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, WebsiteLivelinessResponse{
 			Status:  constants.GENERIC_FAILURE_RESPONSE,
@@ -124,7 +291,7 @@ func (b *BaseController) TestWebsiteLiveliness(ctx *gin.Context) {
 	}
 
 	//check if webiste is live or not before registering it
-	isLive, _, err := b.IsWebsiteLive(request.WebsiteURL)
+	isLive, _, err := b.IsWebsiteLive(request)
 	if err != nil {
 		logger.Error("error in testing website liveliness | err: ", err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, WebsiteLivelinessResponse{
@@ -148,12 +315,37 @@ func (b *BaseController) TestWebsiteLiveliness(ctx *gin.Context) {
 	})
 }
 
-func (b *BaseController) IsWebsiteLive(url string) (bool, int, error) {
+// IsWebsiteLive sends the request of the http monitor the way its checks will, with its method, headers, body and auth
+func (b *BaseController) IsWebsiteLive(request RegisterWebsiteRequest) (bool, int, error) {
 	client := http.Client{
 		Timeout: 5 * time.Second,
 	}
 
-	resp, err := client.Get(url)
+	method := strings.ToUpper(request.HTTPMethod)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if request.HTTPBody != "" {
+		body = strings.NewReader(request.HTTPBody)
+	}
+
+	req, err := http.NewRequest(method, request.WebsiteURL, body)
+	if err != nil {
+		return false, 0, err
+	}
+	for key, value := range request.HTTPHeaders {
+		req.Header.Set(key, value)
+	}
+	switch request.AuthType {
+	case models.AuthTypeBasic:
+		req.SetBasicAuth(request.AuthUsername, request.AuthSecret)
+	case models.AuthTypeBearer:
+		req.Header.Set("Authorization", "Bearer "+request.AuthSecret)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return false, 0, err
 	}
@@ -166,3 +358,417 @@ func (b *BaseController) IsWebsiteLive(url string) (bool, int, error) {
 
 	return false, resp.StatusCode, nil
 }
+
+func websiteErrorMessage(code int) string {
+	if code == http.StatusNotFound {
+		return "Website not found"
+	}
+	return "Something went wrong. Please try again"
+}
+
+// getOwnedWebsite fetches the website of the :uuid path param, scoped to the authenticated user.
+// The returned code is the http status the caller should abort with in case of an error.
+func (b *BaseController) getOwnedWebsite(ctx *gin.Context) (*models.Website, int, error) {
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	website, err := models.InitWebsiteRepo(b.DB).GetWithTx(&models.Website{UUID: ctx.Param("uuid"), UserId: user.ID}, b.DB.WithContext(ctx))
+	if err == gorm.ErrRecordNotFound {
+		return nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return website, http.StatusOK, nil
+}
+
+func (b *BaseController) ListWebsites(ctx *gin.Context) {
+	var (
+		request     ListWebsitesRequest
+		websiteRepo = models.InitWebsiteRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil || (request.HealthStatus != "" && request.HealthStatus != models.Healthy && request.HealthStatus != models.Unhealthy) {
+		logger.Error("invalid request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, ListWebsitesResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+	request.normalize()
+
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		logger.Error("error in getting user from context | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListWebsitesResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	websites, total, err := websiteRepo.FetchWebsitesWithStatus(ctx, models.WebsiteFilter{
+		UserId:       user.ID,
+		HealthStatus: string(request.HealthStatus),
+		Limit:        request.PageSize,
+		Offset:       request.offset(),
+	})
+	if err != nil {
+		logger.Error("error in fetching websites | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, ListWebsitesResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListWebsitesResponse{
+		Status:   constants.GENERIC_SUCCESS_RESPONSE,
+		Message:  "Websites fetched successfully.",
+		Data:     websites,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+		Total:    total,
+	})
+}
+
+func (b *BaseController) GetWebsite(ctx *gin.Context) {
+	var (
+		websiteRepo = models.InitWebsiteRepo(b.DB)
+	)
+
+	user, err := b.GetUserFromContext(ctx)
+	if err != nil {
+		logger.Error("error in getting user from context | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, GetWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	websites, _, err := websiteRepo.FetchWebsitesWithStatus(ctx, models.WebsiteFilter{UserId: user.ID, UUID: ctx.Param("uuid"), Limit: 1})
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, GetWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	if len(websites) == 0 {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, GetWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(http.StatusNotFound),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, GetWebsiteResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Website fetched successfully.",
+		Data:    &websites[0],
+	})
+}
+
+// mergeWebsiteUpdate overlays the partial update on the current website so that the result
+// can be validated the same way as a registration. Settings specific to the previous check type
+// are only carried over when the check type is unchanged, headers and body are the decrypted ones
+// of the website.
+func mergeWebsiteUpdate(website *models.Website, headers map[string]string, body string, request UpdateWebsiteRequest) RegisterWebsiteRequest {
+	merged := RegisterWebsiteRequest{
+		WebsiteURL:      website.WebsiteURL,
+		IntervalSeconds: website.IntervalSeconds,
+		CheckType:       website.CheckType,
+	}
+	if request.CheckType != nil {
+		merged.CheckType = *request.CheckType
+	}
+
+	if merged.CheckType == website.CheckType {
+		switch website.CheckType {
+		case models.CheckTypeDNS:
+			merged.DNSRecordType = website.DNSRecordType
+			merged.DNSExpectedValues = website.DNSExpectedValues
+		case models.CheckTypeHeartbeat:
+			merged.HeartbeatGraceSeconds = website.HeartbeatGraceSeconds
+		case models.CheckTypeFlow:
+			merged.FlowSteps = website.FlowSteps
+			merged.AuthUsername = website.AuthUsername
+			merged.AuthSecret = website.EncryptedAuthSecret
+		case models.CheckTypeHTTP, "":
+			merged.HTTPMethod = website.HTTPMethod
+			merged.HTTPHeaders = headers
+			merged.HTTPBody = body
+			merged.AuthType = website.AuthType
+			merged.AuthUsername = website.AuthUsername
+			//only used to check the presence of a secret, it is never decrypted here
+			merged.AuthSecret = website.EncryptedAuthSecret
+		}
+	}
+
+	if request.WebsiteURL != nil {
+		merged.WebsiteURL = *request.WebsiteURL
+	}
+	if request.IntervalSeconds != nil {
+		merged.IntervalSeconds = *request.IntervalSeconds
+	}
+	if request.DNSRecordType != nil {
+		merged.DNSRecordType = *request.DNSRecordType
+	}
+	if request.DNSExpectedValues != nil {
+		merged.DNSExpectedValues = *request.DNSExpectedValues
+	}
+	if request.HTTPMethod != nil {
+		merged.HTTPMethod = *request.HTTPMethod
+	}
+	if request.HTTPHeaders != nil {
+		merged.HTTPHeaders = *request.HTTPHeaders
+	}
+	if request.HTTPBody != nil {
+		merged.HTTPBody = *request.HTTPBody
+	}
+	if request.AuthType != nil {
+		merged.AuthType = *request.AuthType
+		//switching auth off drops the stored credentials unless they are sent explicitly
+		if merged.AuthType == "" || merged.AuthType == models.AuthTypeNone {
+			merged.AuthUsername = ""
+			merged.AuthSecret = ""
+		}
+	}
+	if request.AuthUsername != nil {
+		merged.AuthUsername = *request.AuthUsername
+	}
+	if request.AuthSecret != nil {
+		merged.AuthSecret = *request.AuthSecret
+	}
+	if request.HeartbeatGraceSeconds != nil {
+		merged.HeartbeatGraceSeconds = *request.HeartbeatGraceSeconds
+	}
+	if request.FlowSteps != nil {
+		merged.FlowSteps = *request.FlowSteps
+	}
+
+	return merged
+}
+
+func (b *BaseController) UpdateWebsite(ctx *gin.Context) {
+	var (
+		request     UpdateWebsiteRequest
+		websiteRepo = models.InitWebsiteRepo(b.DB)
+	)
+
+	err := ctx.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Error("error in binding request | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	headers, body, err := website.HTTPRequestSpec(b.Config.EncryptionKey)
+	if err != nil {
+		logger.Error("error in decrypting request spec | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	merged := mergeWebsiteUpdate(website, headers, body, request)
+	if merged.WebsiteURL == "" || !isValidCheckConfig(merged) || !isValidInterval(merged.CheckType, merged.IntervalSeconds) {
+		logger.Error("invalid request")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Please enter valid details",
+		})
+		return
+	}
+
+	if merged.IntervalSeconds == 0 {
+		merged.IntervalSeconds = constants.DEFAULT_CHECK_INTERVAL_SECONDS
+	}
+	if merged.HTTPMethod == "" {
+		merged.HTTPMethod = http.MethodGet
+	}
+	if merged.AuthType == "" {
+		merged.AuthType = models.AuthTypeNone
+	}
+
+	encryptedAuthSecret := merged.AuthSecret
+	if request.AuthSecret != nil && *request.AuthSecret != "" {
+		encryptedAuthSecret, err = utils.EncryptString(b.Config.EncryptionKey, *request.AuthSecret)
+		if err != nil {
+			logger.Error("error in encrypting auth secret | err: ", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, UpdateWebsiteResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "Something went wrong. Please try again",
+			})
+			return
+		}
+	}
+
+	//the updated configuration is checked right away
+	now := time.Now()
+	updates := &models.Website{
+		WebsiteURL:          merged.WebsiteURL,
+		IntervalSeconds:     merged.IntervalSeconds,
+		NextCheckAt:         now,
+		CheckType:           merged.CheckType,
+		DNSRecordType:       merged.DNSRecordType,
+		DNSExpectedValues:   merged.DNSExpectedValues,
+		HTTPMethod:          strings.ToUpper(merged.HTTPMethod),
+		AuthType:            merged.AuthType,
+		AuthUsername:        merged.AuthUsername,
+		EncryptedAuthSecret: encryptedAuthSecret,
+
+		HeartbeatGraceSeconds: merged.HeartbeatGraceSeconds,
+		FlowSteps:             merged.FlowSteps,
+	}
+	err = updates.SetHTTPRequestSpec(b.Config.EncryptionKey, merged.HTTPHeaders, merged.HTTPBody)
+	if err != nil {
+		logger.Error("error in encrypting request spec | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+	//a heartbeat keeps its ping url, the clock restarts with the new period
+	if merged.CheckType == models.CheckTypeHeartbeat {
+		updates.HeartbeatToken = website.HeartbeatToken
+		err = scheduleHeartbeat(updates, now)
+		if err != nil {
+			logger.Error("error in generating heartbeat token | err: ", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, UpdateWebsiteResponse{
+				Status:  constants.GENERIC_FAILURE_RESPONSE,
+				Message: "Something went wrong. Please try again",
+			})
+			return
+		}
+		updates.NextCheckAt = now
+	}
+	err = websiteRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.Website{ID: website.ID}, updates,
+		"website_url", "interval_seconds", "next_check_at", "check_type", "dns_record_type", "dns_expected_values",
+		"http_method", "encrypted_http_headers", "http_header_names", "encrypted_http_body", "has_http_body", "auth_type", "auth_username", "encrypted_auth_secret",
+		"heartbeat_token", "heartbeat_grace_seconds", "ping_due_at", "flow_steps")
+	if err != nil {
+		logger.Error("error in updating website | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, UpdateWebsiteResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Website updated successfully.",
+	})
+}
+
+func (b *BaseController) DeleteWebsite(ctx *gin.Context) {
+	var (
+		websiteRepo = models.InitWebsiteRepo(b.DB)
+	)
+
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	err = websiteRepo.Delete(&models.Website{ID: website.ID})
+	if err != nil {
+		logger.Error("error in deleting website | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, UpdateWebsiteResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: "Website deleted successfully.",
+	})
+}
+
+func (b *BaseController) PauseWebsite(ctx *gin.Context) {
+	b.setWebsitePaused(ctx, true)
+}
+
+func (b *BaseController) ResumeWebsite(ctx *gin.Context) {
+	b.setWebsitePaused(ctx, false)
+}
+
+func (b *BaseController) setWebsitePaused(ctx *gin.Context, paused bool) {
+	var (
+		websiteRepo = models.InitWebsiteRepo(b.DB)
+		message     = "Website paused successfully."
+	)
+
+	website, code, err := b.getOwnedWebsite(ctx)
+	if err != nil {
+		logger.Error("error in fetching website | err: ", err)
+		ctx.AbortWithStatusJSON(code, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: websiteErrorMessage(code),
+		})
+		return
+	}
+
+	var (
+		now     = time.Now()
+		updates = &models.Website{IsPaused: paused, NextCheckAt: now}
+		columns = []string{"is_paused"}
+	)
+	if !paused {
+		//a resumed website is checked right away
+		columns = append(columns, "next_check_at")
+		message = "Website resumed successfully."
+	}
+	if !paused && website.CheckType == models.CheckTypeHeartbeat {
+		//pings were not expected while paused, the next one is given a full period
+		pingDueAt := now.Add(website.HeartbeatPeriod())
+		updates.PingDueAt = &pingDueAt
+		columns = append(columns, "ping_due_at")
+	}
+
+	err = websiteRepo.UpdateSelectedWithTx(b.DB.WithContext(ctx), &models.Website{ID: website.ID}, updates, columns...)
+	if err != nil {
+		logger.Error("error in updating website pause state | err: ", err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, UpdateWebsiteResponse{
+			Status:  constants.GENERIC_FAILURE_RESPONSE,
+			Message: "Something went wrong. Please try again",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, UpdateWebsiteResponse{
+		Status:  constants.GENERIC_SUCCESS_RESPONSE,
+		Message: message,
+	})
+}
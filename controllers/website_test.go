@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+)
+
+func TestIsWebsiteLiveSendsTheRequestSpec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		username, password, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || r.Header.Get("X-Api-Key") != "key" || string(body) != `{"ping":true}` ||
+			!ok || username != "monitor" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	request := RegisterWebsiteRequest{
+		WebsiteURL:   server.URL,
+		HTTPMethod:   "post",
+		HTTPHeaders:  map[string]string{"X-Api-Key": "key"},
+		HTTPBody:     `{"ping":true}`,
+		AuthType:     models.AuthTypeBasic,
+		AuthUsername: "monitor",
+		AuthSecret:   "secret",
+	}
+
+	isLive, statusCode, err := (&BaseController{}).IsWebsiteLive(request)
+	if err != nil {
+		t.Fatalf("IsWebsiteLive returned error: %v", err)
+	}
+	if !isLive || statusCode != http.StatusNoContent {
+		t.Fatalf("IsWebsiteLive = %v, %d, want live with %d", isLive, statusCode, http.StatusNoContent)
+	}
+
+	request.AuthSecret = "wrong"
+	isLive, statusCode, err = (&BaseController{}).IsWebsiteLive(request)
+	if err != nil {
+		t.Fatalf("IsWebsiteLive returned error: %v", err)
+	}
+	if isLive || statusCode != http.StatusUnauthorized {
+		t.Fatalf("IsWebsiteLive with a wrong secret = %v, %d, want not live with %d", isLive, statusCode, http.StatusUnauthorized)
+	}
+}
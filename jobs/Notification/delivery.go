@@ -0,0 +1,267 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/jobs"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/queue"
+	"gorm.io/gorm"
+)
+
+const deleteTimeout = 10 * time.Second
+
+// processMessage delivers a single queued message. The message is deleted from the queue once
+// the outcome is recorded on the incident event, retries are then driven by RequeueDueRetries
+// instead of the queue's visibility timeout.
+func (nj *notificationJob) processMessage(ctx context.Context, msg *queue.Message) {
+	var (
+		incidentEventsRepo = models.InitIncidentEventsRepo(nj.DB)
+	)
+
+	formattedMsg, err := HandleMessage(msg)
+	if err != nil || formattedMsg.IncidentEventID == "" ||
+		(formattedMsg.Email == "" && formattedMsg.WebhookURL == "" && formattedMsg.Phone == "") {
+		//poison message, it can never be processed so it is dropped instead of being redelivered forever.
+		//the body carries the target and its webhook url, it is never logged
+		if formattedMsg != nil {
+			logger.Errorf("dropping message which can not be processed | %s", formattedMsg)
+		} else {
+			logger.Error("dropping message which can not be parsed")
+		}
+		nj.deleteMessage(msg)
+		return
+	}
+
+	//delivery can outlast the visibility timeout (slow webhooks, voice calls)
+	stopExtending := nj.keepInvisible(ctx, nj.Queue, msg)
+	defer stopExtending()
+
+	incidentEvent, err := incidentEventsRepo.GetWithTx(nj.DB.WithContext(ctx), &models.IncidentEvent{UUID: formattedMsg.IncidentEventID})
+	if err == gorm.ErrRecordNotFound {
+		logger.Error("dropping message of unknown incident event: ", formattedMsg.IncidentEventID)
+		nj.deleteMessage(msg)
+		return
+	}
+	if err != nil {
+		//left in the queue, it is redelivered after the visibility timeout
+		logger.Error("error in fetching incident event | err: ", err)
+		return
+	}
+
+	//the queue delivers at least once, so a message can arrive again after it was handled
+	if incidentEvent.EventStatus != models.EventStatusPending {
+		logger.Info("incident event already handled, skipping: ", incidentEvent.UUID)
+		nj.deleteMessage(msg)
+		return
+	}
+
+	err = nj.deliver(ctx, formattedMsg, incidentEvent)
+	if err != nil {
+		//outcome could not be recorded, let the queue redeliver it
+		return
+	}
+	nj.deleteMessage(msg)
+}
+
+// deleteMessage hands the message to DeleteHandledMessages, which deletes it with the next batch
+func (nj *notificationJob) deleteMessage(msg *queue.Message) {
+	if msg.ReceiptHandle == "" {
+		return
+	}
+	nj.deletes <- msg.ReceiptHandle
+}
+
+// DeleteHandledMessages deletes the handled messages of q in batches until the deletes channel is closed. A batch
+// which fails to delete is redelivered after the visibility timeout and skipped as already handled.
+func (nj *notificationJob) DeleteHandledMessages(q queue.Queue, deletes <-chan string) {
+	var (
+		batch  = make([]string, 0, nj.config.DeleteBatchSize)
+		ticker = time.NewTicker(nj.config.DeleteFlushInterval)
+	)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		//not tied to the job context, the last batch is flushed after it is done
+		ctx, cancel := context.WithTimeout(context.Background(), deleteTimeout)
+		defer cancel()
+
+		err := q.DeleteBatch(ctx, batch)
+		if err != nil {
+			logger.Error("error in deleting msgs from queue | err: ", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case receiptHandle, ok := <-deletes:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, receiptHandle)
+			if len(batch) >= nj.config.DeleteBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// keepInvisible extends the visibility of the message of q every VisibilityExtendInterval until
+// the returned func is called
+func (nj *notificationJob) keepInvisible(ctx context.Context, q queue.Queue, msg *queue.Message) func() {
+	if msg.ReceiptHandle == "" {
+		return func() {}
+	}
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(nj.config.VisibilityExtendInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := q.ExtendVisibility(ctx, msg.ReceiptHandle, nj.config.VisibilityExtension)
+				if err != nil {
+					logger.Error("error in extending msg visibility | err: ", err)
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// deliver sends the message through the notifier of its target type and records the outcome on the
+// incident event, the returned error is only about recording the outcome
+func (nj *notificationJob) deliver(ctx context.Context, formattedMsg *jobs.SQSIncidentEventType, incidentEvent *models.IncidentEvent) error {
+	var (
+		incidentEventsRepo = models.InitIncidentEventsRepo(nj.DB)
+	)
+
+	notifier, err := nj.notifierFor(models.TargetType(formattedMsg.TargetType))
+	if err == nil {
+		err = notifier.Notify(ctx, formattedMsg)
+	}
+
+	updates := &models.IncidentEvent{AttemptCount: incidentEvent.AttemptCount + 1}
+	if err == nil {
+		updates.EventStatus = models.EventStatusDelivered
+	} else {
+		logger.Error("error in sending notification | err: ", err)
+		updates.LastError = err.Error()
+		if updates.AttemptCount >= nj.config.MaxAttempts {
+			logger.Error("notification failed too many times, moving to dead letter: ", incidentEvent.UUID)
+			updates.EventStatus = models.EventStatusDeadLetter
+		} else {
+			nextAttemptAt := time.Now().Add(nj.retryDelay(updates.AttemptCount))
+			updates.EventStatus = models.EventStatusFailed
+			updates.NextAttemptAt = &nextAttemptAt
+		}
+	}
+
+	err = incidentEventsRepo.UpdateSelectedWithTx(nj.DB.WithContext(ctx), &models.IncidentEvent{ID: incidentEvent.ID}, updates,
+		"event_status", "attempt_count", "last_error", "next_attempt_at")
+	if err != nil {
+		logger.Error("error updating incident event status | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// retryDelay is the exponential backoff after the given number of attempts
+func (nj *notificationJob) retryDelay(attempts int) time.Duration {
+	delay := nj.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < nj.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > nj.config.RetryMaxDelay {
+		delay = nj.config.RetryMaxDelay
+	}
+	return delay
+}
+
+// RequeueDueRetries periodically queues the FAILED events whose next attempt is due
+func (nj *notificationJob) RequeueDueRetries(ctx context.Context) {
+	ticker := time.NewTicker(nj.config.RetryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := nj.requeueDueRetries(ctx)
+			if err != nil {
+				logger.Error("error in queueing due retries | err: ", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// requeueDueRetries moves the due events back to PENDING and writes their payload to the outbox in one
+// transaction, the outbox relay then queues them again
+func (nj *notificationJob) requeueDueRetries(ctx context.Context) error {
+	var (
+		incidentEventsRepo = models.InitIncidentEventsRepo(nj.DB)
+		outboxMessagesRepo = models.InitOutboxMessagesRepo(nj.DB)
+	)
+
+	return nj.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		events, err := incidentEventsRepo.ClaimDueRetries(tx, time.Now(), nj.config.RetryBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			body, err := retryPayload(event)
+			if err != nil {
+				logger.Error("incident event has no valid payload, moving to dead letter: ", event.UUID)
+				err = incidentEventsRepo.UpdateSelectedWithTx(tx, &models.IncidentEvent{ID: event.ID},
+					&models.IncidentEvent{EventStatus: models.EventStatusDeadLetter, LastError: "invalid payload"}, "event_status", "last_error", "next_attempt_at")
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			err = incidentEventsRepo.UpdateSelectedWithTx(tx, &models.IncidentEvent{ID: event.ID},
+				&models.IncidentEvent{EventStatus: models.EventStatusPending}, "event_status", "next_attempt_at")
+			if err != nil {
+				return err
+			}
+
+			err = outboxMessagesRepo.CreateWithTx(tx, &models.OutboxMessage{Payload: string(body)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// retryPayload is the stored payload of the event with its id filled in
+func retryPayload(event models.IncidentEvent) ([]byte, error) {
+	var formattedMsg jobs.SQSIncidentEventType
+	err := json.Unmarshal([]byte(event.Payload), &formattedMsg)
+	if err != nil {
+		return nil, err
+	}
+	formattedMsg.IncidentEventID = event.UUID
+
+	return json.Marshal(&formattedMsg)
+}
@@ -4,90 +4,181 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"net/http"
 	"sync"
 	"time"
 
 	controllers "github.com/ankur12345678/uptime-monitor/Controllers"
-	models "github.com/ankur12345678/uptime-monitor/Models"
 	"github.com/ankur12345678/uptime-monitor/jobs"
-	"github.com/ankur12345678/uptime-monitor/pkg/aws"
 	"github.com/ankur12345678/uptime-monitor/pkg/logger"
-	"github.com/ankur12345678/uptime-monitor/pkg/sendgrid"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ankur12345678/uptime-monitor/pkg/queue"
+	"github.com/ankur12345678/uptime-monitor/pkg/telephony"
+	"github.com/ankur12345678/uptime-monitor/utils"
 )
 
 type notificationJob struct {
 	jobs.JobInput
-	config    Config
-	channel   chan *types.Message
-	wg        sync.WaitGroup
-	sqsClient *sqs.Client
+	config     Config
+	channel    chan *queue.Message
+	deletes    chan string
+	wg         sync.WaitGroup
+	httpClient *http.Client
+	telephony  telephony.Provider
+
+	//status page subscribers are served apart from the alerts, see Config.SubscriberWorkerCount
+	subscriberChannel chan *queue.Message
+	subscriberDeletes chan string
+	subscriberLimiter *time.Ticker
+	subscriberClient  *http.Client
 }
 
 type Config struct {
-	WorkerCount   int
-	JobTimeout    time.Duration
+	WorkerCount int
+	JobTimeout  time.Duration
+	// received messages wait here for a worker, their visibility is only extended once a worker picks
+	// them up so the buffer has to drain well within the queue's visibility timeout
 	ChannelBuffer int
+	// each poller receives upto ReceiveBatchSize (at most queue.MaxBatchSize) messages per call
+	PollerCount      int
+	ReceiveBatchSize int
+	// a poller whose receive fails waits ReceiveErrorDelay, doubled on every further failure upto
+	// ReceiveErrorMaxDelay, so an outage of the queue does not turn into a busy loop
+	ReceiveErrorDelay    time.Duration
+	ReceiveErrorMaxDelay time.Duration
+	// handled messages are deleted in batches of DeleteBatchSize, a partial batch is flushed after DeleteFlushInterval
+	DeleteBatchSize     int
+	DeleteFlushInterval time.Duration
+	// a delivery still running after VisibilityExtendInterval gets its message hidden for another
+	// VisibilityExtension, so slow deliveries are not handed to another worker
+	VisibilityExtendInterval time.Duration
+	VisibilityExtension      time.Duration
+	// failed deliveries are retried after RetryBaseDelay * 2^(attempt-1), capped at RetryMaxDelay,
+	// and dead lettered after MaxAttempts
+	MaxAttempts       int
+	RetryBaseDelay    time.Duration
+	RetryMaxDelay     time.Duration
+	RetryPollInterval time.Duration
+	RetryBatchSize    int
+	// status page updates are fanned out by SubscriberWorkerCount workers of their own, in batches of
+	// SubscriberBatchSize subscribers with at most one batch per SubscriberBatchInterval across those
+	// workers, so a large list neither holds up alerts nor floods the mail provider. A fan-out which
+	// keeps failing is given up after SubscriberMaxAttempts.
+	SubscriberWorkerCount   int
+	SubscriberBatchSize     int
+	SubscriberBatchInterval time.Duration
+	SubscriberMaxAttempts   int
 }
 
 func DefaultConfig() Config {
 	return Config{
-		WorkerCount:   128,
-		JobTimeout:    2 * time.Minute,
-		ChannelBuffer: 1000,
+		WorkerCount:              128,
+		JobTimeout:               2 * time.Minute,
+		ChannelBuffer:            1000,
+		PollerCount:              8,
+		ReceiveBatchSize:         queue.MaxBatchSize,
+		ReceiveErrorDelay:        time.Second,
+		ReceiveErrorMaxDelay:     30 * time.Second,
+		DeleteBatchSize:          queue.MaxBatchSize,
+		DeleteFlushInterval:      500 * time.Millisecond,
+		VisibilityExtendInterval: 20 * time.Second,
+		VisibilityExtension:      queue.VisibilityTimeout,
+		MaxAttempts:              5,
+		RetryBaseDelay:           30 * time.Second,
+		RetryMaxDelay:            30 * time.Minute,
+		RetryPollInterval:        10 * time.Second,
+		RetryBatchSize:           100,
+		SubscriberWorkerCount:    2,
+		SubscriberBatchSize:      100,
+		SubscriberBatchInterval:  time.Second,
+		SubscriberMaxAttempts:    5,
 	}
 }
 
-func New(input jobs.JobInput, config Config) *notificationJob {
+func New(input jobs.JobInput, config Config) (*notificationJob, error) {
+	provider, err := telephony.NewProvider(input.BaseController.Config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &notificationJob{
 		JobInput: input,
 		config:   config,
-		channel:  make(chan *types.Message, 1000),
+		channel:  make(chan *queue.Message, config.ChannelBuffer),
+		deletes:  make(chan string, config.ChannelBuffer),
 		wg:       sync.WaitGroup{},
-	}
-}
-
-func (nj *notificationJob) PrepareSQSClient(ctx context.Context) *sqs.Client {
-	awsConfig := aws.LoadAWSConfig(nj.BaseController.Config.AwsProfile, nj.BaseController.Config.AwsRegion)
-	sqsClient := aws.NewClient(awsConfig)
-
-	return sqsClient
+		//shared by the webhook notifiers
+		httpClient: &http.Client{Timeout: webhookTimeout},
+		telephony:  provider,
+		//a fan-out is only received once a worker is about to be free, it stays invisible meanwhile
+		subscriberChannel: make(chan *queue.Message, config.SubscriberWorkerCount),
+		subscriberDeletes: make(chan string, config.SubscriberWorkerCount),
+		subscriberLimiter: time.NewTicker(config.SubscriberBatchInterval),
+		//subscriber webhooks are given by anonymous users, they must not reach the internal network
+		subscriberClient: utils.NewPublicHTTPClient(webhookTimeout),
+	}, nil
 }
 
 func (nj *notificationJob) StartPullingNotificationsFromQueue(ctx context.Context) {
-	sqsClient := nj.PrepareSQSClient(ctx)
+	nj.pullMessages(ctx, nj.Queue, nj.config.ReceiveBatchSize, nj.channel)
+}
 
-	nj.sqsClient = sqsClient
+func (nj *notificationJob) StartPullingSubscriberNotifications(ctx context.Context) {
+	nj.pullMessages(ctx, nj.SubscriberQueue, nj.config.SubscriberWorkerCount, nj.subscriberChannel)
+}
 
+// pullMessages receives from q into channel until ctx is done
+func (nj *notificationJob) pullMessages(ctx context.Context, q queue.Queue, batchSize int, channel chan<- *queue.Message) {
+	errorDelay := nj.config.ReceiveErrorDelay
 	for {
-		//this message wont be visbile to other consumers for visibilty period (30sec)
-		msg, err := aws.ReceiveMessage(sqsClient, nj.BaseController.Config.AwsQueueUrl)
-		if err != nil {
-			logger.Error("error in fetching message from queue | err: ", err)
+		//these messages wont be visbile to other consumers for visibilty period (30sec)
+		msgs, err := q.Receive(ctx, batchSize)
+		if ctx.Err() != nil {
+			logger.Error("context error | err: ", ctx.Err())
+			return
 		}
-		if msg == nil {
+		if err != nil {
+			logger.Error("error in fetching messages from queue | err: ", err)
+			if !sleep(ctx, errorDelay) {
+				return
+			}
+			errorDelay = min(2*errorDelay, nj.config.ReceiveErrorMaxDelay)
 			continue
 		}
-
-		select {
-		case nj.channel <- msg:
-		case <-ctx.Done():
-			logger.Error("context error | err: ", ctx.Err())
-			return
+		errorDelay = nj.config.ReceiveErrorDelay
+
+		for _, msg := range msgs {
+			select {
+			case channel <- msg:
+			case <-ctx.Done():
+				logger.Error("context error | err: ", ctx.Err())
+				return
+			}
 		}
 	}
 }
 
-func HandleMessage(msg *types.Message) (*jobs.SQSIncidentEventType, *string) {
+// sleep waits for d, returning false if the context is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func HandleMessage(msg *queue.Message) (*jobs.SQSIncidentEventType, error) {
 	var formattedMsg jobs.SQSIncidentEventType
-	err := json.Unmarshal([]byte(*msg.Body), &formattedMsg)
+	err := json.Unmarshal(msg.Body, &formattedMsg)
 	if err != nil {
 		log.Println("Failed to parse message body:", err)
-		return nil, nil
+		return nil, err
 	}
-	logger.Infof("Message received : %+v", formattedMsg)
-	return &formattedMsg, msg.ReceiptHandle
+	logger.Infof("Message received | %s", formattedMsg)
+	return &formattedMsg, nil
 }
 
 func Start(ctrl *controllers.BaseController) {
@@ -96,79 +187,64 @@ func Start(ctrl *controllers.BaseController) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.JobTimeout)
 	defer cancel()
 
-	nj := New(jobs.JobInput{BaseController: *ctrl}, cfg)
+	nj, err := New(jobs.NewJobInput(*ctrl), cfg)
+	if err != nil {
+		logger.Fatal("unable to init notification job | err: ", err)
+	}
 
-	go nj.StartPullingNotificationsFromQueue(ctx)
+	defer nj.subscriberLimiter.Stop()
+
+	for poller := 0; poller < nj.config.PollerCount; poller++ {
+		go nj.StartPullingNotificationsFromQueue(ctx)
+	}
+	go nj.StartPullingSubscriberNotifications(ctx)
+	go nj.RequeueDueRetries(ctx)
+
+	deletersDone := sync.WaitGroup{}
+	deletersDone.Add(2)
+	go func() {
+		defer deletersDone.Done()
+		nj.DeleteHandledMessages(nj.Queue, nj.deletes)
+	}()
+	go func() {
+		defer deletersDone.Done()
+		nj.DeleteHandledMessages(nj.SubscriberQueue, nj.subscriberDeletes)
+	}()
 
 	for worker := 0; worker < nj.config.WorkerCount; worker++ {
 		nj.wg.Add(1)
-		go func(ctx context.Context, workerID int) {
-			defer nj.wg.Done()
-			//recovery code
-			defer func() {
-				if r := recover(); r != nil {
-					// r is the panic payload (error, string, etc.)
-					logger.Error("goroutine panicked | err: ", r)
-				}
-			}()
-
-			for {
-				select {
-				case msg := <-nj.channel:
-					var (
-						err error
-					)
-					formattedMsg, receiptHandle := HandleMessage(msg)
-					if formattedMsg.Phone != "" {
-						logger.Error("not supporting notification on phone number!")
-					}
-					if formattedMsg.Email != "" {
-						err = nj.handleEmail(ctx, formattedMsg)
-					}
-
-					if err == nil {
-						//since processing of email is done therfore delete this msg from queue
-						if receiptHandle != nil {
-							err := aws.DeleteMessage(nj.sqsClient, nj.BaseController.Config.AwsQueueUrl, receiptHandle)
-							if err != nil {
-								logger.Error("error in deleting msg from sqs | err: ", err)
-								return
-							}
-						}
-					}
-				case <-ctx.Done():
-					logger.Error("context error | err: ", ctx.Err())
-					return
-				}
-			}
-
-		}(ctx, worker)
+		go nj.runWorker(ctx, nj.channel, nj.processMessage)
+	}
+	for worker := 0; worker < nj.config.SubscriberWorkerCount; worker++ {
+		nj.wg.Add(1)
+		go nj.runWorker(ctx, nj.subscriberChannel, nj.processSubscriberMessage)
 	}
 	nj.wg.Wait()
 
+	//workers are done, flush the messages they handled last
+	close(nj.deletes)
+	close(nj.subscriberDeletes)
+	deletersDone.Wait()
 }
 
-func (nj *notificationJob) handleEmail(ctx context.Context, formattedMsg *jobs.SQSIncidentEventType) error {
-	var (
-		incidentEventsRepo = models.InitIncidentEventsRepo(nj.DB)
-	)
-	err := sendgrid.SendEmail(formattedMsg.Email, "", nj.BaseController.Config, sendgrid.EmailData{WebsiteURL: formattedMsg.WebsiteURL, Status: formattedMsg.Status, Year: time.Now().Year()})
-	if err != nil {
-		logger.Error("error in sending email notification | err: ", err)
-		eventUpdateErr := incidentEventsRepo.UpdateWithTx(nj.DB.WithContext(ctx), &models.IncidentEvent{UUID: formattedMsg.IncidentEventID}, &models.IncidentEvent{EventStatus: models.EventStatusFailed})
-		if eventUpdateErr != nil {
-			logger.Error("error updating incident status to success | err: ", err)
-			return eventUpdateErr
-		}
-		return err
-	} else {
-		err := incidentEventsRepo.UpdateWithTx(nj.DB.WithContext(ctx), &models.IncidentEvent{UUID: formattedMsg.IncidentEventID}, &models.IncidentEvent{EventStatus: models.EventStatusDelivered})
-		if err != nil {
-			logger.Error("error updating incident status to success | err: ", err)
-			return err
+// runWorker handles the messages of channel until ctx is done
+func (nj *notificationJob) runWorker(ctx context.Context, channel <-chan *queue.Message, handle func(context.Context, *queue.Message)) {
+	defer nj.wg.Done()
+	//recovery code
+	defer func() {
+		if r := recover(); r != nil {
+			// r is the panic payload (error, string, etc.)
+			logger.Error("goroutine panicked | err: ", r)
 		}
+	}()
 
+	for {
+		select {
+		case msg := <-channel:
+			handle(ctx, msg)
+		case <-ctx.Done():
+			logger.Error("context error | err: ", ctx.Err())
+			return
+		}
 	}
-
-	return nil
 }
@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/queue"
+)
+
+func TestHandleMessageKeepsTargetsOutOfLogs(t *testing.T) {
+	msg, err := HandleMessage(&queue.Message{Body: []byte(`{"incident_event_id": "ie_1", "target_type": "slack", "alert_target_id": 7,
+		"email": "ops@example.com", "phone_number": "+15550100", "webhook_url": "https://hooks.slack.test/T0/B0/secret",
+		"ack_url": "https://uptime.test/v1/incidents/inc_1/ack?signature=secret"}`)})
+	if err != nil {
+		t.Fatalf("HandleMessage returned error: %v", err)
+	}
+
+	logged := fmt.Sprintf("%+v", msg)
+	for _, secret := range []string{"ops@example.com", "+15550100", "hooks.slack.test", "signature"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("logged message %q contains %q", logged, secret)
+		}
+	}
+	if !strings.Contains(logged, "ie_1") || !strings.Contains(logged, "slack") || !strings.Contains(logged, "7") {
+		t.Errorf("logged message %q does not identify the message", logged)
+	}
+}
+
+// failingQueue fails every receive, the rest of the queue is not used
+type failingQueue struct {
+	queue.Queue
+	receives atomic.Int32
+}
+
+func (fq *failingQueue) Receive(ctx context.Context, max int) ([]*queue.Message, error) {
+	fq.receives.Add(1)
+	return nil, errors.New("connection refused")
+}
+
+func TestPullMessagesBacksOffOnReceiveErrors(t *testing.T) {
+	nj := &notificationJob{config: Config{ReceiveErrorDelay: 50 * time.Millisecond, ReceiveErrorMaxDelay: 100 * time.Millisecond}}
+	q := &failingQueue{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		nj.pullMessages(ctx, q, 1, make(chan *queue.Message))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("pullMessages did not return once the context was done")
+	}
+
+	//waits of 50, 100, 100ms fit in 300ms
+	if receives := q.receives.Load(); receives < 2 || receives > 5 {
+		t.Fatalf("received %d times within 300ms, want a backed off 2-5", receives)
+	}
+}
@@ -0,0 +1,227 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	config "github.com/ankur12345678/uptime-monitor/Config"
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/jobs"
+	"github.com/ankur12345678/uptime-monitor/pkg/sendgrid"
+	"github.com/ankur12345678/uptime-monitor/pkg/telephony"
+	"github.com/ankur12345678/uptime-monitor/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookTimeout = 10 * time.Second
+
+	// generic webhook receivers verify SignatureHeader = "sha256=" + HMAC(secret, timestamp + "." + body)
+	webhookSignatureHeader = "X-Uptime-Signature"
+	webhookTimestampHeader = "X-Uptime-Timestamp"
+)
+
+// Notifier delivers a single incident event to one alert target
+type Notifier interface {
+	Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error
+}
+
+func (nj *notificationJob) notifierFor(targetType models.TargetType) (Notifier, error) {
+	switch targetType {
+	case models.TargetTypeEmail, "":
+		return &emailNotifier{cfg: nj.BaseController.Config}, nil
+	case models.TargetTypeSlack:
+		return &slackNotifier{client: nj.httpClient}, nil
+	case models.TargetTypeDiscord:
+		return &discordNotifier{client: nj.httpClient}, nil
+	case models.TargetTypeTeams:
+		return &teamsNotifier{client: nj.httpClient}, nil
+	case models.TargetTypeSMS:
+		return &smsNotifier{provider: nj.telephony}, nil
+	case models.TargetTypeVoice:
+		return &voiceNotifier{provider: nj.telephony}, nil
+	case models.TargetTypeWebhook:
+		return &webhookNotifier{client: nj.httpClient, db: nj.DB, encryptionKey: nj.BaseController.Config.EncryptionKey}, nil
+	}
+	return nil, fmt.Errorf("unsupported target type: %s", targetType)
+}
+
+// summary is the short text used by the chat and sms notifiers
+func summary(msg *jobs.SQSIncidentEventType) string {
+	text := fmt.Sprintf("%s is %s", msg.WebsiteURL, msg.Status)
+	if msg.Reason != "" {
+		text += fmt.Sprintf("\nReason: %s", msg.Reason)
+	}
+	if msg.AckURL != "" {
+		text += fmt.Sprintf("\nAcknowledge: %s", msg.AckURL)
+	}
+	return text
+}
+
+// postJSON posts payload to url and treats any non 2xx response as a failed delivery
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type emailNotifier struct {
+	cfg *config.Creds
+}
+
+func (en *emailNotifier) Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error {
+	return sendgrid.SendEmail(msg.Email, "", en.cfg, sendgrid.EmailData{
+		WebsiteURL: msg.WebsiteURL,
+		Status:     msg.Status,
+		Reason:     msg.Reason,
+		AckURL:     msg.AckURL,
+		Year:       time.Now().Year(),
+	})
+}
+
+// smsNotifier texts the summary to the phone number of the target
+type smsNotifier struct {
+	provider telephony.Provider
+}
+
+func (sn *smsNotifier) Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error {
+	return sn.provider.SendSMS(ctx, msg.Phone, summary(msg))
+}
+
+// voiceNotifier calls the target and reads out the status, links can not be read out so they are skipped
+type voiceNotifier struct {
+	provider telephony.Provider
+}
+
+func (vn *voiceNotifier) Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error {
+	message := fmt.Sprintf("Alert from the uptime monitor. %s is %s.", msg.WebsiteURL, msg.Status)
+	if msg.Reason != "" {
+		message += fmt.Sprintf(" Reason: %s.", msg.Reason)
+	}
+	return vn.provider.Call(ctx, msg.Phone, message)
+}
+
+// slackNotifier posts to a slack incoming webhook
+type slackNotifier struct {
+	client *http.Client
+}
+
+func (sn *slackNotifier) Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error {
+	return postJSON(ctx, sn.client, msg.WebhookURL, map[string]string{"text": summary(msg)}, nil)
+}
+
+// discordNotifier posts to a discord channel webhook
+type discordNotifier struct {
+	client *http.Client
+}
+
+func (dn *discordNotifier) Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error {
+	return postJSON(ctx, dn.client, msg.WebhookURL, map[string]string{"content": summary(msg)}, nil)
+}
+
+// teamsNotifier posts a MessageCard to a microsoft teams incoming webhook
+type teamsNotifier struct {
+	client *http.Client
+}
+
+func (tn *teamsNotifier) Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error {
+	themeColor := "2EB67D"
+	switch msg.Status {
+	case string(models.Unhealthy):
+		themeColor = "E01E5A"
+	case string(models.CertificateExpiring):
+		themeColor = "ECB22E"
+	}
+
+	card := map[string]string{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    fmt.Sprintf("%s is %s", msg.WebsiteURL, msg.Status),
+		"themeColor": themeColor,
+		"title":      "Website Status Update",
+		"text":       summary(msg),
+	}
+	return postJSON(ctx, tn.client, msg.WebhookURL, card, nil)
+}
+
+// webhookPayload is the body of the generic webhook
+type webhookPayload struct {
+	EventID    string `json:"event_id"`
+	WebsiteURL string `json:"website_url"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+	AckURL     string `json:"ack_url,omitempty"`
+	SentAt     int64  `json:"sent_at"`
+}
+
+// webhookNotifier posts a json payload signed with the secret of the alert target
+type webhookNotifier struct {
+	client        *http.Client
+	db            *gorm.DB
+	encryptionKey string
+}
+
+func (wn *webhookNotifier) Notify(ctx context.Context, msg *jobs.SQSIncidentEventType) error {
+	target, err := models.InitAlertTargetRepo(wn.db).GetWithTx(wn.db.WithContext(ctx), &models.AlertTarget{ID: msg.AlertTargetID})
+	if err != nil {
+		return fmt.Errorf("unable to fetch alert target: %w", err)
+	}
+
+	secret, err := utils.DecryptString(wn.encryptionKey, target.EncryptedSigningSecret)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt signing secret: %w", err)
+	}
+
+	return postSignedWebhook(ctx, wn.client, target.TargetValue, secret, msg, time.Now())
+}
+
+// postSignedWebhook posts the webhookPayload of msg to url, signed with secret
+func postSignedWebhook(ctx context.Context, client *http.Client, url, secret string, msg *jobs.SQSIncidentEventType, now time.Time) error {
+	payload := webhookPayload{
+		EventID:    msg.IncidentEventID,
+		WebsiteURL: msg.WebsiteURL,
+		Status:     msg.Status,
+		Reason:     msg.Reason,
+		AckURL:     msg.AckURL,
+		SentAt:     now.Unix(),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	headers := map[string]string{
+		webhookTimestampHeader: timestamp,
+		webhookSignatureHeader: "sha256=" + utils.SignHMAC(secret, timestamp+"."+string(body)),
+	}
+	return postJSON(ctx, client, url, json.RawMessage(body), headers)
+}
@@ -0,0 +1,147 @@
+package notification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/jobs"
+)
+
+// capturedRequest is a request received by the test server
+type capturedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newCaptureServer(t *testing.T, status int) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+	requests := make(chan capturedRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- capturedRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func testIncidentMessage(webhookURL string) *jobs.SQSIncidentEventType {
+	return &jobs.SQSIncidentEventType{
+		WebsiteURL:      "https://example.com",
+		WebhookURL:      webhookURL,
+		Status:          string(models.Unhealthy),
+		Reason:          "unhealthy status code: 503",
+		IncidentEventID: "evt_1",
+		AckURL:          "https://uptime.example.com/v1/incidents/1/ack",
+	}
+}
+
+func decodeBody(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+	var decoded map[string]interface{}
+	err := json.Unmarshal(body, &decoded)
+	if err != nil {
+		t.Fatalf("body is not json: %v (%s)", err, body)
+	}
+	return decoded
+}
+
+func TestChatNotifierPayloads(t *testing.T) {
+	tests := []struct {
+		name     string
+		notifier func(client *http.Client) Notifier
+		field    string
+	}{
+		{name: "slack", notifier: func(client *http.Client) Notifier { return &slackNotifier{client: client} }, field: "text"},
+		{name: "discord", notifier: func(client *http.Client) Notifier { return &discordNotifier{client: client} }, field: "content"},
+		{name: "teams", notifier: func(client *http.Client) Notifier { return &teamsNotifier{client: client} }, field: "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newCaptureServer(t, http.StatusOK)
+			msg := testIncidentMessage(server.URL)
+
+			err := tt.notifier(server.Client()).Notify(context.Background(), msg)
+			if err != nil {
+				t.Fatalf("Notify returned error: %v", err)
+			}
+
+			req := <-requests
+			if contentType := req.header.Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+			payload := decodeBody(t, req.body)
+			if payload[tt.field] != summary(msg) {
+				t.Errorf("%s = %v, want %q", tt.field, payload[tt.field], summary(msg))
+			}
+		})
+	}
+}
+
+func TestTeamsNotifierMessageCard(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+
+	err := (&teamsNotifier{client: server.Client()}).Notify(context.Background(), testIncidentMessage(server.URL))
+	if err != nil {
+		t.Fatalf("Notify returned error: %v", err)
+	}
+
+	payload := decodeBody(t, (<-requests).body)
+	if payload["@type"] != "MessageCard" || payload["themeColor"] != "E01E5A" || payload["summary"] != "https://example.com is UNHEALTHY" {
+		t.Errorf("unexpected message card: %v", payload)
+	}
+}
+
+func TestNotifierFailsOnNon2xx(t *testing.T) {
+	server, _ := newCaptureServer(t, http.StatusInternalServerError)
+
+	err := (&slackNotifier{client: server.Client()}).Notify(context.Background(), testIncidentMessage(server.URL))
+	if err == nil {
+		t.Fatal("Notify should fail when the webhook responds with 500")
+	}
+}
+
+func TestPostSignedWebhook(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusNoContent)
+	msg := testIncidentMessage("")
+	now := time.Unix(1700000000, 0)
+
+	err := postSignedWebhook(context.Background(), server.Client(), server.URL, "signing-secret", msg, now)
+	if err != nil {
+		t.Fatalf("postSignedWebhook returned error: %v", err)
+	}
+
+	req := <-requests
+	timestamp := req.header.Get(webhookTimestampHeader)
+	if timestamp != strconv.FormatInt(now.Unix(), 10) {
+		t.Errorf("%s = %q, want %d", webhookTimestampHeader, timestamp, now.Unix())
+	}
+
+	mac := hmac.New(sha256.New, []byte("signing-secret"))
+	mac.Write([]byte(timestamp + "." + string(req.body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if signature := req.header.Get(webhookSignatureHeader); signature != want {
+		t.Errorf("%s = %q, want %q", webhookSignatureHeader, signature, want)
+	}
+
+	var payload webhookPayload
+	err = json.Unmarshal(req.body, &payload)
+	if err != nil {
+		t.Fatalf("body is not a webhook payload: %v", err)
+	}
+	if payload.EventID != msg.IncidentEventID || payload.WebsiteURL != msg.WebsiteURL || payload.Status != msg.Status ||
+		payload.Reason != msg.Reason || payload.AckURL != msg.AckURL || payload.SentAt != now.Unix() {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+}
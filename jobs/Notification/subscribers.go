@@ -0,0 +1,199 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/jobs"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/queue"
+	"github.com/ankur12345678/uptime-monitor/pkg/sendgrid"
+	"gorm.io/gorm"
+)
+
+// subscriberWebhookPayload is posted to webhook subscribers of a status page for every update
+type subscriberWebhookPayload struct {
+	Event          models.SubscriberEvent `json:"event"`
+	StatusPage     string                 `json:"status_page"`
+	StatusPageURL  string                 `json:"status_page_url"`
+	Component      string                 `json:"component"`
+	UnsubscribeURL string                 `json:"unsubscribe_url"`
+	SentAt         int64                  `json:"sent_at"`
+}
+
+// processSubscriberMessage fans a status page update out to the confirmed subscribers of the page. A
+// fan-out which fails is left on the queue and carries on from its cursor once redelivered.
+func (nj *notificationJob) processSubscriberMessage(ctx context.Context, msg *queue.Message) {
+	var (
+		subscriberNotificationsRepo = models.InitSubscriberNotificationsRepo(nj.DB)
+		formattedMsg                jobs.SubscriberNotificationMessage
+	)
+
+	err := json.Unmarshal(msg.Body, &formattedMsg)
+	if err != nil || formattedMsg.SubscriberNotificationID == "" {
+		logger.Error("dropping subscriber message which can not be processed | body: ", string(msg.Body))
+		nj.deleteSubscriberMessage(msg)
+		return
+	}
+
+	//a large list is sent over many rate limited batches
+	stopExtending := nj.keepInvisible(ctx, nj.SubscriberQueue, msg)
+	defer stopExtending()
+
+	notification, err := subscriberNotificationsRepo.GetWithTx(nj.DB.WithContext(ctx), &models.SubscriberNotification{UUID: formattedMsg.SubscriberNotificationID})
+	if err == gorm.ErrRecordNotFound {
+		logger.Error("dropping message of unknown subscriber notification: ", formattedMsg.SubscriberNotificationID)
+		nj.deleteSubscriberMessage(msg)
+		return
+	}
+	if err != nil {
+		logger.Error("error in fetching subscriber notification | err: ", err)
+		return
+	}
+
+	if notification.CompletedAt != nil {
+		logger.Info("subscriber notification already sent: ", notification.UUID)
+		nj.deleteSubscriberMessage(msg)
+		return
+	}
+
+	err = nj.fanOut(ctx, notification)
+	if err != nil {
+		return
+	}
+	nj.deleteSubscriberMessage(msg)
+}
+
+// deleteSubscriberMessage hands the message to the DeleteHandledMessages of the subscriber queue
+func (nj *notificationJob) deleteSubscriberMessage(msg *queue.Message) {
+	if msg.ReceiptHandle == "" {
+		return
+	}
+	nj.subscriberDeletes <- msg.ReceiptHandle
+}
+
+// fanOut notifies the subscribers after the cursor of the notification, one batch per tick of the
+// subscriber limiter. It returns an error when the fan-out should be retried later.
+func (nj *notificationJob) fanOut(ctx context.Context, notification *models.SubscriberNotification) error {
+	var (
+		statusPagesRepo             = models.InitStatusPagesRepo(nj.DB)
+		subscribersRepo             = models.InitStatusPageSubscribersRepo(nj.DB)
+		subscriberNotificationsRepo = models.InitSubscriberNotificationsRepo(nj.DB)
+	)
+
+	page, err := statusPagesRepo.GetWithTx(nj.DB.WithContext(ctx), &models.StatusPage{ID: notification.StatusPageId})
+	if err == gorm.ErrRecordNotFound {
+		return nj.completeFanOut(ctx, notification, "status page was deleted")
+	}
+	if err != nil {
+		logger.Error("error in fetching status page of subscriber notification | err: ", err)
+		return err
+	}
+
+	for {
+		subscribers, err := subscribersRepo.ListConfirmedAfter(nj.DB.WithContext(ctx), page.ID, notification.LastSubscriberId, nj.config.SubscriberBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(subscribers) == 0 {
+			return nj.completeFanOut(ctx, notification, "")
+		}
+
+		select {
+		case <-nj.subscriberLimiter.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		err = nj.sendSubscriberBatch(ctx, page, notification, subscribers)
+		if err != nil {
+			logger.Error("error in sending subscriber batch | err: ", err)
+			notification.Attempts++
+			notification.LastError = err.Error()
+			if notification.Attempts >= nj.config.SubscriberMaxAttempts {
+				logger.Error("giving up on subscriber notification: ", notification.UUID)
+				return nj.completeFanOut(ctx, notification, notification.LastError)
+			}
+
+			updateErr := subscriberNotificationsRepo.UpdateSelectedWithTx(nj.DB.WithContext(ctx), &models.SubscriberNotification{ID: notification.ID}, notification, "attempts", "last_error")
+			if updateErr != nil {
+				return updateErr
+			}
+			return err
+		}
+
+		notification.LastSubscriberId = subscribers[len(subscribers)-1].ID
+		err = subscriberNotificationsRepo.UpdateSelectedWithTx(nj.DB.WithContext(ctx), &models.SubscriberNotification{ID: notification.ID}, notification, "last_subscriber_id")
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (nj *notificationJob) completeFanOut(ctx context.Context, notification *models.SubscriberNotification, lastError string) error {
+	var (
+		subscriberNotificationsRepo = models.InitSubscriberNotificationsRepo(nj.DB)
+		now                         = time.Now()
+	)
+
+	notification.CompletedAt = &now
+	notification.LastError = lastError
+	return subscriberNotificationsRepo.UpdateSelectedWithTx(nj.DB.WithContext(ctx), &models.SubscriberNotification{ID: notification.ID}, notification, "completed_at", "last_error")
+}
+
+// sendSubscriberBatch mails the email subscribers of the batch in a single request and posts to the webhook
+// subscribers. The email goes first as a failure retries the whole batch, webhooks are only posted once it is
+// sent so they are not delivered twice. Webhooks are best effort, a broken endpoint of one subscriber must not
+// hold up the rest.
+func (nj *notificationJob) sendSubscriberBatch(ctx context.Context, page *models.StatusPage, notification *models.SubscriberNotification, subscribers []models.StatusPageSubscriber) error {
+	var (
+		cfg        = nj.Config
+		pageURL    = page.PublicURL(cfg.ServerBaseUrl)
+		recipients []sendgrid.SubscriberRecipient
+		webhooks   []models.StatusPageSubscriber
+	)
+
+	unsubscribeURL := func(subscriber models.StatusPageSubscriber) string {
+		return fmt.Sprintf("%s/v1/status-subscribers/unsubscribe/%s", cfg.ServerBaseUrl, subscriber.UnsubscribeToken)
+	}
+
+	for _, subscriber := range subscribers {
+		switch subscriber.Type {
+		case models.SubscriberTypeEmail:
+			recipients = append(recipients, sendgrid.SubscriberRecipient{Email: subscriber.Target, UnsubscribeURL: unsubscribeURL(subscriber)})
+		case models.SubscriberTypeWebhook:
+			webhooks = append(webhooks, subscriber)
+		}
+	}
+
+	if len(recipients) > 0 {
+		err := sendgrid.SendSubscriberUpdates(recipients, cfg, sendgrid.SubscriberUpdateEmailData{
+			PageTitle: page.Title,
+			PageURL:   pageURL,
+			Component: notification.Component,
+			Resolved:  notification.Event == models.SubscriberEventIncidentResolved,
+			Year:      time.Now().Year(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, subscriber := range webhooks {
+		err := postJSON(ctx, nj.subscriberClient, subscriber.Target, subscriberWebhookPayload{
+			Event:          notification.Event,
+			StatusPage:     page.Title,
+			StatusPageURL:  pageURL,
+			Component:      notification.Component,
+			UnsubscribeURL: unsubscribeURL(subscriber),
+			SentAt:         time.Now().Unix(),
+		}, nil)
+		if err != nil {
+			logger.Error("error in posting to subscriber webhook | err: ", err)
+		}
+	}
+	return nil
+}
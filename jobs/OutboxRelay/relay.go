@@ -0,0 +1,174 @@
+package outboxrelay
+
+import (
+	"context"
+	"time"
+
+	controllers "github.com/ankur12345678/uptime-monitor/Controllers"
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/jobs"
+	"github.com/ankur12345678/uptime-monitor/pkg/graceful"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// Config holds job configuration parameters
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// published messages are kept this long for debugging before being cleaned up
+	Retention       time.Duration
+	CleanupInterval time.Duration
+	// a message which fails to publish is retried with exponential backoff, then marked dead
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// DefaultConfig returns default configuration values
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:    time.Second,
+		BatchSize:       100,
+		Retention:       7 * 24 * time.Hour,
+		CleanupInterval: time.Hour,
+		MaxAttempts:     10,
+		RetryBaseDelay:  5 * time.Second,
+		RetryMaxDelay:   10 * time.Minute,
+	}
+}
+
+type outboxRelayJob struct {
+	jobs.JobInput
+	config Config
+}
+
+func New(input jobs.JobInput, config Config) *outboxRelayJob {
+	return &outboxRelayJob{
+		JobInput: input,
+		config:   config,
+	}
+}
+
+// RelayBatch publishes the oldest due outbox messages to the queue. A message is marked sent in
+// the same transaction that locked it, so if the commit fails it is published again; consumers
+// already skip incident events which were handled. A message which fails to publish is put off
+// (see retryDelay) without holding up the rest of the batch.
+func (r *outboxRelayJob) RelayBatch(ctx context.Context) (int, error) {
+	var (
+		outboxMessagesRepo = models.InitOutboxMessagesRepo(r.DB)
+		sent               int
+	)
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		messages, err := outboxMessagesRepo.ClaimUnsent(tx, r.config.BatchSize, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			q := r.Queue
+			if message.Topic == models.OutboxTopicSubscriberNotifications {
+				q = r.SubscriberQueue
+			}
+
+			err := q.Send(ctx, []byte(message.Payload))
+			if err != nil {
+				logger.Error("error in publishing outbox message | err: ", err)
+				updateErr := r.failMessage(tx, &message, err)
+				if updateErr != nil {
+					return updateErr
+				}
+				continue
+			}
+
+			now := time.Now()
+			err = outboxMessagesRepo.UpdateSelectedWithTx(tx, &models.OutboxMessage{ID: message.ID},
+				&models.OutboxMessage{SentAt: &now, Attempts: message.Attempts + 1}, "sent_at", "attempts")
+			if err != nil {
+				return err
+			}
+			sent++
+		}
+		return nil
+	})
+	return sent, err
+}
+
+// failMessage records the failed attempt of the message, it is retried after retryDelay or marked
+// dead once it used up the max attempts
+func (r *outboxRelayJob) failMessage(tx *gorm.DB, message *models.OutboxMessage, sendErr error) error {
+	var (
+		now     = time.Now()
+		updates = &models.OutboxMessage{Attempts: message.Attempts + 1, LastError: sendErr.Error()}
+	)
+
+	if updates.Attempts >= r.config.MaxAttempts {
+		logger.Error("giving up on outbox message: ", message.ID)
+		updates.DeadAt = &now
+	} else {
+		nextAttemptAt := now.Add(r.retryDelay(updates.Attempts))
+		updates.NextAttemptAt = &nextAttemptAt
+	}
+
+	return models.InitOutboxMessagesRepo(r.DB).UpdateSelectedWithTx(tx, &models.OutboxMessage{ID: message.ID}, updates,
+		"attempts", "last_error", "next_attempt_at", "dead_at")
+}
+
+// retryDelay is the exponential backoff after the given number of attempts
+func (r *outboxRelayJob) retryDelay(attempts int) time.Duration {
+	delay := r.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < r.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.config.RetryMaxDelay {
+		delay = r.config.RetryMaxDelay
+	}
+	return delay
+}
+
+func (r *outboxRelayJob) cleanup(ctx context.Context) {
+	deleted, err := models.InitOutboxMessagesRepo(r.DB).DeleteSentBefore(r.DB.WithContext(ctx), time.Now().Add(-r.config.Retention))
+	if err != nil {
+		logger.Error("error in cleaning up outbox | err: ", err)
+		return
+	}
+	logger.Info("cleaned up sent outbox messages: ", deleted)
+}
+
+// Run relays until ctx is done, a full batch is followed by the next one right away
+func (r *outboxRelayJob) Run(ctx context.Context) {
+	lastCleanup := time.Time{}
+
+	for {
+		if time.Since(lastCleanup) >= r.config.CleanupInterval {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		sent, err := r.RelayBatch(ctx)
+		if err != nil {
+			logger.Error("error in relaying outbox messages | err: ", err)
+		}
+		if err == nil && sent == r.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-time.After(r.config.PollInterval):
+		case <-ctx.Done():
+			logger.Info("stopping outbox relay")
+			return
+		}
+	}
+}
+
+// RunOutboxRelay keeps publishing the outbox until SIGTERM
+func RunOutboxRelay(ctrl controllers.BaseController) {
+	job := New(jobs.NewJobInput(ctrl), DefaultConfig())
+
+	ctx, stop := graceful.ShutdownContext(context.Background(), &graceful.ServerState{})
+	defer stop()
+
+	job.Run(ctx)
+}
@@ -0,0 +1,129 @@
+package websitepicker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// inspectCertificate describes the leaf certificate of the connection and verifies its chain
+// against the system roots for serverName
+func inspectCertificate(state tls.ConnectionState, serverName string) *models.CertificateInfo {
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	sans := append([]string{}, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	var (
+		expiresAt = leaf.NotAfter
+		checkedAt = time.Now()
+	)
+	info := &models.CertificateInfo{
+		ExpiresAt: &expiresAt,
+		Issuer:    leaf.Issuer.String(),
+		Subject:   leaf.Subject.String(),
+		SANs:      sans,
+		CheckedAt: &checkedAt,
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: serverName, Intermediates: intermediates})
+	info.ChainValid = err == nil
+	if err != nil {
+		info.ChainError = err.Error()
+	}
+	return info
+}
+
+// fetchCertificate completes a handshake without verification, so that the certificate can be
+// described even when it is expired or untrusted
+func fetchCertificate(ctx context.Context, address, serverName string) (*models.CertificateInfo, error) {
+	dialer := tls.Dialer{Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true}}
+
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return inspectCertificate(conn.(*tls.Conn).ConnectionState(), serverName), nil
+}
+
+// isCertificateError tells whether the request failed on certificate verification rather than on the network
+func isCertificateError(err error) bool {
+	var (
+		verificationErr *tls.CertificateVerificationError
+		unknownAuthErr  x509.UnknownAuthorityError
+		hostnameErr     x509.HostnameError
+		invalidErr      x509.CertificateInvalidError
+	)
+	return errors.As(err, &verificationErr) || errors.As(err, &unknownAuthErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// recordCertificate keeps the latest certificate details on the website and alerts the first escalation
+// level once per certificate when it expires within AlertConfig.CertExpiryAlertDays
+func (w *websitePickerJob) recordCertificate(ctx context.Context, website models.Website, alertConfig *models.AlertConfig, cert *models.CertificateInfo) {
+	if cert == nil || cert.ExpiresAt == nil {
+		return
+	}
+
+	var (
+		websiteRepo = models.InitWebsiteRepo(w.DB)
+		expiresIn   = time.Until(*cert.ExpiresAt)
+	)
+
+	err := w.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := websiteRepo.UpdateSelectedWithTx(tx, &models.Website{ID: website.ID}, &models.Website{Certificate: *cert},
+			"cert_expires_at", "cert_issuer", "cert_subject", "cert_sans", "cert_chain_valid", "cert_chain_error", "cert_checked_at")
+		if err != nil {
+			return err
+		}
+
+		if alertConfig.CertExpiryAlertDays <= 0 || expiresIn > time.Duration(alertConfig.CertExpiryAlertDays)*24*time.Hour {
+			return nil
+		}
+
+		//only the location which records the alert first sends it
+		marked, err := websiteRepo.MarkCertificateAlerted(tx, website.ID, *cert.ExpiresAt)
+		if err != nil || !marked {
+			return err
+		}
+
+		reason := fmt.Sprintf("certificate expires in %d days (on %s)", int(expiresIn.Hours()/24), cert.ExpiresAt.Format(time.RFC1123))
+		if expiresIn <= 0 {
+			reason = fmt.Sprintf("certificate expired on %s", cert.ExpiresAt.Format(time.RFC1123))
+		}
+		logger.Info("notifying user that certificate is expiring: ", website.WebsiteURL)
+		return w.notifyTargets(ctx, tx, alertConfig, &website, 0, models.CertificateExpiring, reason, "", 1, 1)
+	})
+	if err != nil {
+		logger.Error("error in recording certificate | err: ", err)
+	}
+}
+
+// tlsAddress is host:port of the website for tls connections along with the hostname to verify
+func tlsAddress(hostPort string) (string, string) {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return net.JoinHostPort(hostPort, defaultTLSPort), hostPort
+	}
+	return hostPort, host
+}
@@ -0,0 +1,271 @@
+package websitepicker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/assertions"
+	"github.com/ankur12345678/uptime-monitor/utils"
+)
+
+const (
+	// non-http checks do not have a status code of their own, so a successful
+	// probe is reported as 200 and a failed one as 0 (see isUnhealthyStatus)
+	checkPassedStatusCode = http.StatusOK
+	checkFailedStatusCode = 0
+
+	defaultTLSPort = "443"
+
+	// only this much of the body is kept in memory for assertions, the rest is counted and discarded
+	maxResponseBodyBytes = 1 << 20
+
+	defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// CheckResult is the common shape every checker produces and is what feeds CreateOrResolveIncident.
+// Response is only set by checkers which have a response to assert on (http) and Certificate
+// by the ones which went through a tls handshake (https, tls). Checkers evaluating their own
+// assertions (flow) set Failure instead, along with a log of every step.
+type CheckResult struct {
+	StatusCode  int
+	Latency     time.Duration
+	Err         error
+	Response    *assertions.Response
+	Certificate *models.CertificateInfo
+	Failure     *healthEvaluation
+	Steps       []models.StepLog
+}
+
+// Checker probes a single website according to its CheckType
+type Checker interface {
+	Check(ctx context.Context, website models.Website) CheckResult
+}
+
+func (w *websitePickerJob) checkerFor(checkType models.CheckType) (Checker, error) {
+	switch checkType {
+	case models.CheckTypeHTTP, "":
+		return &httpChecker{client: &w.httpClient, encryptionKey: w.BaseController.Config.EncryptionKey}, nil
+	case models.CheckTypeTCP:
+		return &tcpChecker{}, nil
+	case models.CheckTypeDNS:
+		return &dnsChecker{resolver: net.DefaultResolver}, nil
+	case models.CheckTypeTLS:
+		return &tlsChecker{}, nil
+	case models.CheckTypeFlow:
+		return &flowChecker{client: &w.httpClient, encryptionKey: w.BaseController.Config.EncryptionKey}, nil
+	}
+	return nil, fmt.Errorf("unsupported check type: %s", checkType)
+}
+
+func failedResult(latency time.Duration, err error) CheckResult {
+	return CheckResult{StatusCode: checkFailedStatusCode, Latency: latency, Err: err}
+}
+
+type httpChecker struct {
+	client        *http.Client
+	encryptionKey string
+}
+
+func (hc *httpChecker) Check(ctx context.Context, website models.Website) CheckResult {
+	req, err := hc.buildRequest(ctx, website)
+	if err != nil {
+		return failedResult(0, err)
+	}
+
+	start := time.Now()
+	resp, err := hc.client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		result := failedResult(latency, err)
+		//the certificate is what broke the request, describe it for the expiry/chain details
+		if req.URL.Scheme == "https" && isCertificateError(err) {
+			address, serverName := tlsAddress(req.URL.Host)
+			result.Certificate, _ = fetchCertificate(ctx, address, serverName)
+		}
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes))
+	if err != nil {
+		return failedResult(latency, err)
+	}
+	remaining, _ := io.Copy(io.Discard, resp.Body)
+
+	//the certificate of the final response, after redirects
+	var certificate *models.CertificateInfo
+	if resp.TLS != nil {
+		certificate = inspectCertificate(*resp.TLS, resp.Request.URL.Hostname())
+	}
+
+	return CheckResult{
+		StatusCode:  resp.StatusCode,
+		Latency:     latency,
+		Certificate: certificate,
+		Response: &assertions.Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       body,
+			BodySize:   int64(len(body)) + remaining,
+		},
+	}
+}
+
+// buildRequest prepares the request configured for the monitor, falling back to a browser like GET
+func (hc *httpChecker) buildRequest(ctx context.Context, website models.Website) (*http.Request, error) {
+	method := website.HTTPMethod
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	headers, httpBody, err := website.HTTPRequestSpec(hc.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt request spec: %w", err)
+	}
+
+	var body io.Reader
+	if httpBody != "" {
+		body = strings.NewReader(httpBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, normalizeURL(website.WebsiteURL), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+
+	//headers configured on the monitor take precedence over the defaults above
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if website.AuthType == models.AuthTypeBasic || website.AuthType == models.AuthTypeBearer {
+		secret, err := utils.DecryptString(hc.encryptionKey, website.EncryptedAuthSecret)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt auth secret: %w", err)
+		}
+
+		if website.AuthType == models.AuthTypeBasic {
+			req.SetBasicAuth(website.AuthUsername, secret)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+	}
+
+	return req, nil
+}
+
+// tcpChecker only opens (and closes) a connection to host:port, useful for databases, smtp relays etc.
+type tcpChecker struct{}
+
+func (tc *tcpChecker) Check(ctx context.Context, website models.Website) CheckResult {
+	var dialer net.Dialer
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", website.WebsiteURL)
+	latency := time.Since(start)
+	if err != nil {
+		return failedResult(latency, err)
+	}
+	conn.Close()
+
+	return CheckResult{StatusCode: checkPassedStatusCode, Latency: latency}
+}
+
+// dnsChecker resolves the hostname and, if expected values are configured, makes sure all of them are returned
+type dnsChecker struct {
+	resolver *net.Resolver
+}
+
+func (dc *dnsChecker) Check(ctx context.Context, website models.Website) CheckResult {
+	var (
+		records []string
+		err     error
+	)
+
+	start := time.Now()
+	switch website.DNSRecordType {
+	case models.DNSRecordTypeCNAME:
+		var cname string
+		cname, err = dc.resolver.LookupCNAME(ctx, website.WebsiteURL)
+		records = []string{cname}
+	case models.DNSRecordTypeAAAA:
+		records, err = dc.lookupIP(ctx, "ip6", website.WebsiteURL)
+	default:
+		records, err = dc.lookupIP(ctx, "ip4", website.WebsiteURL)
+	}
+	latency := time.Since(start)
+	if err != nil {
+		return failedResult(latency, err)
+	}
+
+	if len(records) == 0 {
+		return failedResult(latency, fmt.Errorf("no %s records found for %s", website.DNSRecordType, website.WebsiteURL))
+	}
+
+	for _, expected := range website.DNSExpectedValues {
+		if !containsRecord(records, expected) {
+			return failedResult(latency, fmt.Errorf("expected record %s not found, got: %v", expected, records))
+		}
+	}
+
+	return CheckResult{StatusCode: checkPassedStatusCode, Latency: latency}
+}
+
+func (dc *dnsChecker) lookupIP(ctx context.Context, network, host string) ([]string, error) {
+	ips, err := dc.resolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		records = append(records, ip.String())
+	}
+	return records, nil
+}
+
+func containsRecord(records []string, expected string) bool {
+	expected = strings.TrimSuffix(strings.ToLower(expected), ".")
+	for _, record := range records {
+		if strings.TrimSuffix(strings.ToLower(record), ".") == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// tlsChecker completes a tls handshake (including certificate verification) against host[:port]
+type tlsChecker struct{}
+
+func (tc *tlsChecker) Check(ctx context.Context, website models.Website) CheckResult {
+	address, serverName := tlsAddress(website.WebsiteURL)
+
+	//verified below, so that the certificate is described even when it does not verify
+	start := time.Now()
+	certificate, err := fetchCertificate(ctx, address, serverName)
+	latency := time.Since(start)
+	if err != nil {
+		return failedResult(latency, err)
+	}
+
+	if certificate == nil || !certificate.ChainValid {
+		result := failedResult(latency, fmt.Errorf("certificate verification failed"))
+		if certificate != nil {
+			result.Err = fmt.Errorf("certificate verification failed: %s", certificate.ChainError)
+		}
+		result.Certificate = certificate
+		return result
+	}
+
+	return CheckResult{StatusCode: checkPassedStatusCode, Latency: latency, Certificate: certificate}
+}
@@ -0,0 +1,168 @@
+package websitepicker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/assertions"
+	"github.com/ankur12345678/uptime-monitor/pkg/flow"
+	"github.com/ankur12345678/uptime-monitor/utils"
+)
+
+// flowChecker runs the steps of the website in order, sharing the extracted variables and cookies between them.
+// The flow stops at the first failing step, the result carries a StepLog for every step which ran.
+type flowChecker struct {
+	client        *http.Client
+	encryptionKey string
+}
+
+func (fc *flowChecker) Check(ctx context.Context, website models.Website) CheckResult {
+	variables := map[string]string{flow.AuthUsernameVariable: website.AuthUsername}
+	if website.EncryptedAuthSecret != "" {
+		secret, err := utils.DecryptString(fc.encryptionKey, website.EncryptedAuthSecret)
+		if err != nil {
+			return failedResult(0, fmt.Errorf("unable to decrypt auth secret: %w", err))
+		}
+		variables[flow.AuthSecretVariable] = secret
+	}
+
+	//cookies set by a step (eg. a login) are sent by the later ones, but never across checks
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return failedResult(0, err)
+	}
+	client := *fc.client
+	client.Jar = jar
+
+	result := CheckResult{StatusCode: checkPassedStatusCode}
+	for i, step := range website.FlowSteps {
+		name := flow.StepName(step, i)
+
+		statusCode, latency, failure := fc.runStep(ctx, &client, step, variables)
+		result.Latency += latency
+
+		stepLog := models.StepLog{
+			Position:     i + 1,
+			Name:         name,
+			StatusCode:   uint(statusCode),
+			LatencyInMS:  uint(latency.Milliseconds()),
+			HealthStatus: string(models.Healthy),
+		}
+		if failure != nil {
+			stepLog.HealthStatus = string(models.Unhealthy)
+			stepLog.FailureReason = failure.Reason
+			result.Steps = append(result.Steps, stepLog)
+
+			failure.Reason = fmt.Sprintf("%s failed: %s", name, failure.Reason)
+			failure.FailingStep = name
+			result.StatusCode = statusCode
+			result.Failure = failure
+			return result
+		}
+		result.Steps = append(result.Steps, stepLog)
+	}
+	return result
+}
+
+// runStep sends the request of the step, evaluates its assertions and stores its extractions in variables
+func (fc *flowChecker) runStep(ctx context.Context, client *http.Client, step models.FlowStep, variables map[string]string) (int, time.Duration, *healthEvaluation) {
+	req, err := buildStepRequest(ctx, step, variables)
+	if err != nil {
+		err = withStepURL(err, step)
+		return checkFailedStatusCode, 0, &healthEvaluation{Reason: err.Error(), ErrorText: err.Error()}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		err = withStepURL(err, step)
+		return checkFailedStatusCode, latency, &healthEvaluation{Reason: err.Error(), ErrorText: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes))
+	if err != nil {
+		return resp.StatusCode, latency, &healthEvaluation{Reason: err.Error(), ErrorText: err.Error()}
+	}
+	remaining, _ := io.Copy(io.Discard, resp.Body)
+
+	response := assertions.Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		BodySize:   int64(len(body)) + remaining,
+	}
+
+	//a status code assertion replaces the default 4xx/5xx evaluation
+	if !assertions.HasStatusCodeAssertion(step.Assertions) && isUnhealthyStatus(resp.StatusCode) {
+		return resp.StatusCode, latency, &healthEvaluation{Reason: fmt.Sprintf("unhealthy status code: %d", resp.StatusCode)}
+	}
+	if failingAssertion := assertions.Evaluate(step.Assertions, response); failingAssertion != "" {
+		return resp.StatusCode, latency, &healthEvaluation{Reason: failingAssertion, FailingAssertion: failingAssertion}
+	}
+
+	for _, extraction := range step.Extract {
+		value, err := flow.Extract(extraction, response)
+		if err != nil {
+			return resp.StatusCode, latency, &healthEvaluation{Reason: fmt.Sprintf("extracting %s failed: %s", extraction.Variable, err.Error())}
+		}
+		variables[extraction.Variable] = value
+	}
+	return resp.StatusCode, latency, nil
+}
+
+// withStepURL puts the url of the step, as written, into url errors in place of the substituted one, failure
+// reasons are shown to the user and must not carry extracted tokens or the auth secret
+func withStepURL(err error, step models.FlowStep) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = step.URL
+	}
+	return err
+}
+
+// buildStepRequest prepares the request of the step with its variables substituted
+func buildStepRequest(ctx context.Context, step models.FlowStep, variables map[string]string) (*http.Request, error) {
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	target, err := flow.SubstituteURL(step.URL, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if step.Body != "" {
+		substituted, err := flow.Substitute(step.Body, variables)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(substituted)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, normalizeURL(target), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", defaultUserAgent)
+	for key, value := range step.Headers {
+		substituted, err := flow.Substitute(value, variables)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(key, substituted)
+	}
+	return req, nil
+}
@@ -0,0 +1,33 @@
+package websitepicker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+)
+
+func TestRunStepKeepsVariablesOutOfFailureReason(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	var (
+		checker   = flowChecker{client: &http.Client{}}
+		step      = models.FlowStep{URL: server.URL + "/me?token={{token}}"}
+		variables = map[string]string{"token": "s3cr3t-token"}
+	)
+
+	_, _, failure := checker.runStep(context.Background(), checker.client, step, variables)
+	if failure == nil {
+		t.Fatal("runStep against a closed server returned no failure")
+	}
+	if strings.Contains(failure.Reason, "s3cr3t-token") || strings.Contains(failure.ErrorText, "s3cr3t-token") {
+		t.Errorf("failure carries the substituted url: %q", failure.Reason)
+	}
+	if !strings.Contains(failure.Reason, "{{token}}") {
+		t.Errorf("failure reason %q does not name the step url", failure.Reason)
+	}
+}
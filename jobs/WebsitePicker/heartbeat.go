@@ -0,0 +1,163 @@
+package websitepicker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// SweepHeartbeats evaluates the heartbeats which are due. Every location sweeps, the heartbeats are locked
+// one by one and skipped once another location moved their NextCheckAt.
+func (w *websitePickerJob) SweepHeartbeats(ctx context.Context) {
+	ids, err := models.InitWebsiteRepo(w.DB).FetchDueHeartbeatIDs(ctx, constants.HEARTBEAT_SWEEP_BATCH_SIZE)
+	if err != nil {
+		return
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		w.evaluateHeartbeat(ctx, id)
+	}
+}
+
+// heartbeatStatus is Unhealthy when the latest run reported a failure or the next ping is overdue. The failure
+// threshold of the alert config does not apply, the grace time already gives late jobs room.
+func (w *websitePickerJob) heartbeatStatus(tx *gorm.DB, website *models.Website, now time.Time) (models.HealthStatus, string, error) {
+	if website.LastPingSignal == models.HeartbeatSignalFail {
+		ping, err := models.InitHeartbeatPingsRepo(w.DB).GetLatestWithTx(tx, website.ID, models.HeartbeatSignalFail)
+		if err != nil {
+			logger.Error("error in fetching failed ping of heartbeat | err: ", err)
+			return "", "", err
+		}
+		return models.Unhealthy, ping.FailureReason(), nil
+	}
+
+	if website.PingDueAt == nil || !now.After(*website.PingDueAt) {
+		return models.Healthy, "", nil
+	}
+
+	switch {
+	case website.LastPingAt == nil:
+		return models.Unhealthy, "no ping received since the heartbeat was set up", nil
+	case website.LastPingSignal == models.HeartbeatSignalStart:
+		return models.Unhealthy, fmt.Sprintf("run started at %s did not finish within the grace time", website.LastPingAt.UTC().Format(time.RFC3339)), nil
+	}
+	return models.Unhealthy, fmt.Sprintf("no ping received since %s", website.LastPingAt.UTC().Format(time.RFC3339)), nil
+}
+
+// evaluateHeartbeat opens, escalates or resolves the incident of the heartbeat the same way failed checks do
+func (w *websitePickerJob) evaluateHeartbeat(ctx context.Context, websiteID uint) {
+	var (
+		alertConfigRepo = models.InitAlertConfigRepo(w.DB)
+		incidentsRepo   = models.InitIncidentsRepo(w.DB)
+		logsRepo        = models.InitLogsRepo(w.DB)
+		websiteRepo     = models.InitWebsiteRepo(w.DB)
+	)
+
+	err := w.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := websiteRepo.LockWithTx(tx, websiteID)
+		if err != nil {
+			return err
+		}
+
+		website, err := websiteRepo.GetWithTx(&models.Website{ID: websiteID}, tx)
+		if err != nil {
+			logger.Error("error in fetching heartbeat | err: ", err)
+			return err
+		}
+
+		now := time.Now()
+		//evaluated by another location meanwhile, or paused/changed since it was fetched
+		if website.IsPaused || website.CheckType != models.CheckTypeHeartbeat || website.NextCheckAt.After(now) {
+			return nil
+		}
+
+		status, reason, err := w.heartbeatStatus(tx, website, now)
+		if err != nil {
+			return err
+		}
+
+		//a down heartbeat is looked at again for escalations, an up one once its ping is due
+		nextCheckAt := now.Add(constants.HEARTBEAT_RECHECK_SECONDS * time.Second)
+		if status == models.Healthy && website.PingDueAt != nil {
+			nextCheckAt = *website.PingDueAt
+		}
+		err = websiteRepo.UpdateSelectedWithTx(tx, &models.Website{ID: websiteID}, &models.Website{NextCheckAt: nextCheckAt}, "next_check_at")
+		if err != nil {
+			return err
+		}
+
+		inMaintenance := w.isInMaintenance(ctx, *website, now)
+
+		//failed runs are logged when they are reported, a missing ping is logged on every look at the heartbeat
+		if status == models.Unhealthy && website.LastPingSignal != models.HeartbeatSignalFail {
+			//written with the incident work, a rolled back evaluation would otherwise log the missing ping twice
+			err = logsRepo.CreateWithTx(tx, models.Log{
+				WebsiteId:     websiteID,
+				HealthStatus:  string(status),
+				Location:      models.HeartbeatLocation,
+				InMaintenance: inMaintenance,
+				FailureReason: reason,
+			})
+			if err != nil {
+				logger.Error("error in creating log | err: ", err)
+				return err
+			}
+		}
+
+		if inMaintenance {
+			//the job may be stopped on purpose, the heartbeat is evaluated again once the window ends
+			logger.Info("heartbeat is under maintenance, skipping incident evaluation: ", websiteID)
+			return nil
+		}
+
+		alertConfig, err := alertConfigRepo.GetWithTx(tx, &models.AlertConfig{WebsiteID: websiteID})
+		if err != nil {
+			logger.Error("error in fetching alert config for this heartbeat | err: ", err)
+			return err
+		}
+
+		pastIncident, err := incidentsRepo.GetUnresolvedByWebsiteID(tx, websiteID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			logger.Error("error in fetching previous incidents | err: ", err)
+			return err
+		}
+		noUnresolvedIncident := err == gorm.ErrRecordNotFound
+
+		if status == models.Unhealthy {
+			if noUnresolvedIncident {
+				return w.openIncident(ctx, tx, alertConfig, &models.Incident{
+					WebsiteId:     websiteID,
+					HealthStatus:  string(status),
+					StartedAt:     now,
+					FailureReason: reason,
+				})
+			}
+
+			err := incidentsRepo.UpdateSelectedWithTx(tx, &models.Incident{ID: pastIncident.ID}, &models.Incident{
+				FailureReason: reason,
+			}, "failure_reason")
+			if err != nil {
+				logger.Error("error in updating incident root cause | err: ", err)
+				return err
+			}
+			return w.escalateIncident(ctx, tx, alertConfig, pastIncident, status, reason, now)
+		}
+
+		if noUnresolvedIncident {
+			return nil
+		}
+		return w.resolveIncident(ctx, tx, alertConfig, pastIncident, now)
+	})
+	if err != nil {
+		//nothing was committed, the heartbeat stays due and is evaluated again by the next sweep
+		logger.Error("error in evaluating heartbeat | err: ", err)
+	}
+}
@@ -0,0 +1,60 @@
+package websitepicker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"gorm.io/gorm"
+)
+
+// sentCounter answers CountSentSince with a fixed count, the rest of the repo is not used
+type sentCounter struct {
+	models.IIncidentEvent
+	count int64
+	err   error
+
+	alertTargetID uint
+	since         time.Time
+}
+
+func (sc *sentCounter) CountSentSince(tx *gorm.DB, alertTargetID uint, since time.Time) (int64, error) {
+	sc.alertTargetID = alertTargetID
+	sc.since = since
+	return sc.count, sc.err
+}
+
+func TestPhoneTargetRateLimited(t *testing.T) {
+	now := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		counter *sentCounter
+		want    bool
+	}{
+		{name: "no alerts sent", counter: &sentCounter{count: 0}, want: false},
+		{name: "below the limit", counter: &sentCounter{count: constants.PHONE_ALERT_RATE_LIMIT - 1}, want: false},
+		{name: "at the limit", counter: &sentCounter{count: constants.PHONE_ALERT_RATE_LIMIT}, want: true},
+		{name: "over the limit", counter: &sentCounter{count: constants.PHONE_ALERT_RATE_LIMIT + 3}, want: true},
+		{name: "failed count is not limited", counter: &sentCounter{count: 100, err: errors.New("connection refused")}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := phoneTargetRateLimited(tt.counter, nil, 42, now)
+			if got != tt.want {
+				t.Errorf("phoneTargetRateLimited() = %v, want %v", got, tt.want)
+			}
+
+			if tt.counter.alertTargetID != 42 {
+				t.Errorf("counted alert target %d, want 42", tt.counter.alertTargetID)
+			}
+			wantSince := now.Add(-constants.PHONE_ALERT_RATE_LIMIT_WINDOW_MINUTES * time.Minute)
+			if !tt.counter.since.Equal(wantSince) {
+				t.Errorf("counted since %v, want %v", tt.counter.since, wantSince)
+			}
+		})
+	}
+}
@@ -2,7 +2,8 @@ package websitepicker
 
 import (
 	"context"
-	"io"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
@@ -11,9 +12,11 @@ import (
 	controllers "github.com/ankur12345678/uptime-monitor/Controllers"
 	models "github.com/ankur12345678/uptime-monitor/Models"
 	"github.com/ankur12345678/uptime-monitor/jobs"
-	"github.com/ankur12345678/uptime-monitor/pkg/aws"
+	"github.com/ankur12345678/uptime-monitor/pkg/assertions"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/graceful"
 	"github.com/ankur12345678/uptime-monitor/pkg/logger"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/ankur12345678/uptime-monitor/utils"
 	"gorm.io/gorm"
 )
 
@@ -24,6 +27,9 @@ type Config struct {
 	ChannelBuffer      int
 	JobTimeout         time.Duration
 	HealthCheckTimeout time.Duration
+	// daemon mode only
+	PollInterval time.Duration
+	DrainTimeout time.Duration
 }
 
 // DefaultConfig returns default configuration values
@@ -34,6 +40,8 @@ func DefaultConfig() Config {
 		ChannelBuffer:      1000,
 		JobTimeout:         2 * time.Minute,
 		HealthCheckTimeout: 50 * time.Second,
+		PollInterval:       5 * time.Second,
+		DrainTimeout:       time.Minute,
 	}
 }
 
@@ -43,7 +51,8 @@ type websitePickerJob struct {
 	websitesChan chan models.Website
 	wg           sync.WaitGroup
 	httpClient   http.Client
-	sqsClient    *sqs.Client
+	//probe location this instance checks from, every location checks every website
+	location string
 }
 
 func New(input jobs.JobInput, config Config) *websitePickerJob {
@@ -63,9 +72,11 @@ func New(input jobs.JobInput, config Config) *websitePickerJob {
 	// 	ResponseHeaderTimeout: 5 * time.Second, // wait this long for first byte
 	// 	// (body read still governed by ctx or client.Timeout)
 	// }
-	awsCfg := aws.LoadAWSConfig(input.BaseController.Config.AwsProfile, input.BaseController.Config.AwsRegion)
+	location := input.BaseController.Config.ProbeLocation
+	if location == "" {
+		location = constants.DEFAULT_PROBE_LOCATION
+	}
 
-	sqsClient := aws.NewClient(awsCfg)
 	return &websitePickerJob{
 		JobInput:     input,
 		config:       config,
@@ -75,19 +86,25 @@ func New(input jobs.JobInput, config Config) *websitePickerJob {
 			Timeout: config.HealthCheckTimeout,
 			// Transport: transport,
 		},
-		sqsClient: sqsClient,
+		location: location,
 	}
 }
 
+// UpdateAllWebsiteLastCheckedTime marks the websites as checked and schedules their next check from this location
+// after their own interval plus a jitter of upto 10% of it, so monitors do not stay clustered together
 func (w *websitePickerJob) UpdateAllWebsiteLastCheckedTime(ctx context.Context, tx *gorm.DB, websites []models.Website) error {
 	ids := make([]uint, 0, len(websites))
 	for _, w := range websites {
 		ids = append(ids, w.ID)
 	}
 
+	now := time.Now()
 	err := tx.WithContext(ctx).Model(&models.Website{}).
 		Where("id IN ?", ids).
-		Updates(map[string]interface{}{"last_checked_at": time.Now()}).
+		Updates(map[string]interface{}{
+			"last_checked_at": now,
+			"next_check_at":   gorm.Expr("?::timestamptz + make_interval(secs => interval_seconds * (1 + random() * 0.1))", now),
+		}).
 		Error
 
 	if err != nil {
@@ -95,9 +112,29 @@ func (w *websitePickerJob) UpdateAllWebsiteLastCheckedTime(ctx context.Context,
 		return err
 	}
 
+	err = tx.WithContext(ctx).Exec(`
+		INSERT INTO website_location_checks (website_id, location, last_checked_at, next_check_at)
+		SELECT id, ?, ?::timestamptz, ?::timestamptz + make_interval(secs => interval_seconds * (1 + random() * 0.1))
+		FROM websites WHERE id IN ?
+		ON CONFLICT (website_id, location) DO UPDATE
+		SET last_checked_at = EXCLUDED.last_checked_at, next_check_at = EXCLUDED.next_check_at
+	`, w.location, now, now, ids).Error
+	if err != nil {
+		logger.Error("error in scheduling next check of location | err: ", err)
+		return err
+	}
+
 	return nil
 }
 
+// registerLocation marks the probe location of this job as active, see constants.PROBE_LOCATION_ACTIVE_MINUTES
+func (w *websitePickerJob) registerLocation(ctx context.Context) {
+	err := models.InitProbeLocationsRepo(w.DB).Register(w.DB.WithContext(ctx), w.location)
+	if err != nil {
+		logger.Error("error in registering probe location | err: ", err)
+	}
+}
+
 func normalizeURL(url string) string {
 	if !strings.Contains(url, "://") {
 		return "https://" + url
@@ -117,11 +154,11 @@ func (w *websitePickerJob) FetchWebsitesForJob(ctx context.Context) error {
 		default:
 		}
 
-		websites, tx, err := websiteRepo.FetchWebsitesInBulk(ctx, 10)
+		websites, tx, err := websiteRepo.FetchWebsitesInBulk(ctx, w.location, w.config.BatchSize)
 
+		//tx is already rolled back (and nil) on error
 		if err != nil {
 			logger.Error("error in fetching webistes | err: ", err)
-			tx.Rollback()
 			return err
 		}
 
@@ -178,11 +215,102 @@ func isUnhealthyStatus(code int) bool {
 	return code >= 400 || code == 0
 }
 
-func (w *websitePickerJob) CreateOrResolveIncident(ctx context.Context, webisteID uint, statusCode int, latency time.Duration) {
+// healthEvaluation explains the outcome of a check, every field is empty when it is healthy
+type healthEvaluation struct {
+	Reason           string //why the check is unhealthy
+	ErrorText        string //transport level error (dns, connect, tls, timeout ...)
+	FailingAssertion string
+	FailingStep      string //name of the failing step of flow checks
+}
+
+func evaluateHealth(alertConfig *models.AlertConfig, result CheckResult) healthEvaluation {
+	if result.Err != nil {
+		return healthEvaluation{Reason: result.Err.Error(), ErrorText: result.Err.Error()}
+	}
+	if result.Failure != nil {
+		return *result.Failure
+	}
+
+	//a status code assertion replaces the default 4xx/5xx evaluation
+	if (result.Response == nil || !assertions.HasStatusCodeAssertion(alertConfig.Assertions)) && isUnhealthyStatus(result.StatusCode) {
+		return healthEvaluation{Reason: fmt.Sprintf("unhealthy status code: %d", result.StatusCode)}
+	}
+
+	if alertConfig.LatencyThreshold > 0 && result.Latency.Milliseconds() >= int64(alertConfig.LatencyThreshold) {
+		return healthEvaluation{Reason: fmt.Sprintf("latency of %dms breached the threshold of %dms", result.Latency.Milliseconds(), alertConfig.LatencyThreshold)}
+	}
+
+	if result.Response != nil {
+		failingAssertion := assertions.Evaluate(alertConfig.Assertions, *result.Response)
+		return healthEvaluation{Reason: failingAssertion, FailingAssertion: failingAssertion}
+	}
+	return healthEvaluation{}
+}
+
+// locationQuorumStatus is Unhealthy when at least LocationQuorum probe locations saw FailureThreshold failed checks
+// in a row. Only locations which checked the website recently count, and the quorum is capped at the number of
+// active locations so that losing a location does not stop incidents from being opened. The returned bool tells
+// whether any location has enough checks to decide on.
+func (w *websitePickerJob) locationQuorumStatus(ctx context.Context, tx *gorm.DB, website models.Website, alertConfig *models.AlertConfig) (models.HealthStatus, bool, error) {
+	var (
+		logsRepo           = models.InitLogsRepo(w.DB)
+		probeLocationsRepo = models.InitProbeLocationsRepo(w.DB)
+		now                = time.Now()
+	)
+
+	//twice the time the last FailureThreshold checks take, leaving room for the jitter and late picks
+	since := now.Add(-2 * time.Duration(alertConfig.FailureThreshold*website.IntervalSeconds) * time.Second)
+	statuses, err := logsRepo.FetchRecentStatusesByLocation(ctx, website.ID, since, alertConfig.FailureThreshold)
+	if err != nil {
+		return "", false, err
+	}
+
+	statusesByLocation := map[string][]string{}
+	for _, status := range statuses {
+		statusesByLocation[status.Location] = append(statusesByLocation[status.Location], status.HealthStatus)
+	}
+
+	decidedLocations, unhealthyLocations := 0, 0
+	for location, statusRecords := range statusesByLocation {
+		if len(statusRecords) != alertConfig.FailureThreshold {
+			//not enough data from this location yet
+			continue
+		}
+		decidedLocations++
+		if identifyCummulativeStatusBasedOnPastRecords(statusRecords) == models.Unhealthy {
+			logger.Info("website is down from location: ", location)
+			unhealthyLocations++
+		}
+	}
+	if decidedLocations == 0 {
+		return "", false, nil
+	}
+
+	quorum := alertConfig.LocationQuorum
+	if quorum < 1 {
+		quorum = 1
+	}
+	activeLocations, err := probeLocationsRepo.CountActiveSince(tx, now.Add(-constants.PROBE_LOCATION_ACTIVE_MINUTES*time.Minute))
+	if err != nil {
+		return "", false, err
+	}
+	if activeLocations > 0 && int64(quorum) > activeLocations {
+		quorum = int(activeLocations)
+	}
+
+	if unhealthyLocations >= quorum {
+		return models.Unhealthy, true, nil
+	}
+	return models.Healthy, true, nil
+}
+
+func (w *websitePickerJob) CreateOrResolveIncident(ctx context.Context, website models.Website, result CheckResult) {
 	var (
 		alertConfigRepo = models.InitAlertConfigRepo(w.DB)
 		logsRepo        = models.InitLogsRepo(w.DB)
 		incidentsRepo   = models.InitIncidentsRepo(w.DB)
+		websiteRepo     = models.InitWebsiteRepo(w.DB)
+		webisteID       = website.ID
 		status          models.HealthStatus
 	)
 
@@ -192,72 +320,200 @@ func (w *websitePickerJob) CreateOrResolveIncident(ctx context.Context, webisteI
 		return
 	}
 
-	if latency.Milliseconds() >= int64(alertConfig.LatencyThreshold) || isUnhealthyStatus(statusCode) {
+	evaluation := evaluateHealth(alertConfig, result)
+	failureReason := evaluation.Reason
+	if failureReason != "" {
 		status = models.Unhealthy
 	} else {
 		status = models.Healthy
 	}
 
-	err = logsRepo.Create(ctx, models.Log{
-		WebsiteId:    webisteID,
-		StatusCode:   uint(statusCode),
-		LatencyInMS:  uint(latency.Milliseconds()),
-		HealthStatus: string(status),
-	})
+	inMaintenance := w.isInMaintenance(ctx, website, time.Now())
+
+	log := models.Log{
+		WebsiteId:     webisteID,
+		StatusCode:    uint(result.StatusCode),
+		LatencyInMS:   uint(result.Latency.Milliseconds()),
+		HealthStatus:  string(status),
+		Location:      w.location,
+		InMaintenance: inMaintenance,
+		FailureReason: failureReason,
+	}
+	if result.Steps != nil {
+		err = logsRepo.CreateWithSteps(ctx, log, result.Steps)
+	} else {
+		err = logsRepo.Create(ctx, log)
+	}
 	if err != nil {
 		logger.Error("error in creating log | err: ", err)
 		return
 	}
 
-	statusRecords, err := logsRepo.FetchPastRecordStatusByWebsiteID(ctx, uint(alertConfig.FailureThreshold), webisteID)
-	if err != nil {
-		logger.Error("error in fetching log records for incidents | err: ", err)
+	if inMaintenance {
+		//the website may be down on purpose, nothing is opened, resolved or notified until the window ends
+		logger.Info("website is under maintenance, skipping incident evaluation: ", webisteID)
 		return
 	}
 
-	if len(statusRecords) != alertConfig.FailureThreshold {
-		//since we dont have enough data to create incidents/notify therefore get back from here
-		return
-	}
+	w.recordCertificate(ctx, website, alertConfig, result.Certificate)
 
-	currentCummulativeStatus := identifyCummulativeStatusBasedOnPastRecords(statusRecords)
+	//the incident state change and the notifications it causes are committed together, the
+	//notifications reach the queue through the outbox relay (see models.OutboxMessage)
+	err = w.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		//locations checking the website at the same time take their decision one after the other
+		err := websiteRepo.LockWithTx(tx, webisteID)
+		if err != nil {
+			return err
+		}
 
-	//fetch past incident
-	pastStatus, err := incidentsRepo.GetWithTx(w.DB.WithContext(ctx), &models.Incident{WebsiteId: webisteID})
-	if err != nil && err != gorm.ErrRecordNotFound {
-		logger.Error("error in fetching previous incidents | err: ", err)
-		return
-	}
+		quorumStatus, enoughData, err := w.locationQuorumStatus(ctx, tx, website, alertConfig)
+		if err != nil {
+			logger.Error("error in fetching log records for incidents | err: ", err)
+			return err
+		}
+		if !enoughData {
+			//since we dont have enough data to create incidents/notify therefore get back from here
+			return nil
+		}
 
-	if err == gorm.ErrRecordNotFound {
-		if currentCummulativeStatus == models.Unhealthy {
-			//enter record in incident table and notify to user
-			err := incidentsRepo.Create(w.DB.WithContext(ctx), models.Incident{WebsiteId: webisteID, HealthStatus: string(status)})
-			if err != nil {
-				logger.Error("error in creating incident record | err: ", err)
-				return
+		//fetch the incident which is not resolved yet
+		pastIncident, err := incidentsRepo.GetUnresolvedByWebsiteID(tx, webisteID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			logger.Error("error in fetching previous incidents | err: ", err)
+			return err
+		}
+		noUnresolvedIncident := err == gorm.ErrRecordNotFound
+
+		now := time.Now()
+		if noUnresolvedIncident {
+			//opened by a failed check, the locations still seeing the website up do not open it
+			if status != models.Unhealthy || quorumStatus != models.Unhealthy {
+				return nil
 			}
-			logger.Info("notifying user that website is down!")
-			w.notifyUser(ctx, alertConfig.ID, status, webisteID)
+
+			return w.openIncident(ctx, tx, alertConfig, &models.Incident{
+				WebsiteId:        webisteID,
+				HealthStatus:     string(status),
+				StartedAt:        now,
+				FailureReason:    failureReason,
+				LastStatusCode:   uint(result.StatusCode),
+				ErrorText:        evaluation.ErrorText,
+				FailingAssertion: evaluation.FailingAssertion,
+				FailingStep:      evaluation.FailingStep,
+			})
 		}
-	} else {
-		if pastStatus.HealthStatus == string(models.Unhealthy) && status == (models.Unhealthy) {
-			logger.Info("notifying user that website is down!")
-			w.notifyUser(ctx, alertConfig.ID, status, webisteID)
-		} else if pastStatus.HealthStatus == string(models.Unhealthy) && status == (models.Healthy) {
-			//notufy user that webiste is up and delete the incident
-			err := incidentsRepo.DeleteWithTx(w.DB.WithContext(ctx), &models.Incident{ID: pastStatus.ID})
+
+		if quorumStatus == models.Unhealthy {
+			if status != models.Unhealthy {
+				//this location sees the website up, but the quorum still agrees it is down
+				return nil
+			}
+
+			//keep the root cause of the incident up to date
+			err := incidentsRepo.UpdateSelectedWithTx(tx, &models.Incident{ID: pastIncident.ID}, &models.Incident{
+				FailureReason:    failureReason,
+				LastStatusCode:   uint(result.StatusCode),
+				ErrorText:        evaluation.ErrorText,
+				FailingAssertion: evaluation.FailingAssertion,
+				FailingStep:      evaluation.FailingStep,
+			}, "failure_reason", "last_status_code", "error_text", "failing_assertion", "failing_step")
 			if err != nil {
-				logger.Error("error in deleting incident record | err: ", err)
-				return
+				logger.Error("error in updating incident root cause | err: ", err)
+				return err
 			}
 
-			//push to SQS for notification
-			logger.Info("notifying user that website is up!")
-			w.notifyUser(ctx, alertConfig.ID, status, webisteID)
+			return w.escalateIncident(ctx, tx, alertConfig, pastIncident, status, failureReason, now)
 		}
+
+		//this check may still have failed when other locations stopped agreeing on the failure
+		return w.resolveIncident(ctx, tx, alertConfig, pastIncident, now)
+	})
+	if err != nil {
+		//nothing was committed, the next check of the website tries again
+		logger.Error("error in updating incident | err: ", err)
+	}
+}
+
+// openIncident records the incident as open and notifies the first escalation level along with the subscribers
+// of the status pages showing the website
+func (w *websitePickerJob) openIncident(ctx context.Context, tx *gorm.DB, alertConfig *models.AlertConfig, incident *models.Incident) error {
+	incident.State = models.IncidentStateOpen
+	incident.EscalationLevel = 1
+	incident.LastNotifiedAt = &incident.StartedAt
+
+	err := models.InitIncidentsRepo(w.DB).Create(tx, incident)
+	if err != nil {
+		logger.Error("error in creating incident record | err: ", err)
+		return err
+	}
+
+	err = w.notifySubscribers(tx, incident, models.SubscriberEventIncidentOpened)
+	if err != nil {
+		return err
+	}
+	logger.Info("notifying user that website is down!")
+	return w.notifyUser(ctx, tx, alertConfig, incident, models.Unhealthy, incident.FailureReason, 1, 1)
+}
+
+// resolveIncident resolves the incident and tells everyone who was told about it about the recovery
+func (w *websitePickerJob) resolveIncident(ctx context.Context, tx *gorm.DB, alertConfig *models.AlertConfig, incident *models.Incident, now time.Time) error {
+	err := models.InitIncidentsRepo(w.DB).UpdateSelectedWithTx(tx, &models.Incident{ID: incident.ID}, &models.Incident{
+		State:             models.IncidentStateResolved,
+		ResolvedAt:        &now,
+		DurationInSeconds: int64(now.Sub(incident.StartedAt).Seconds()),
+	}, "state", "resolved_at", "duration_in_seconds")
+	if err != nil {
+		logger.Error("error in resolving incident record | err: ", err)
+		return err
+	}
+
+	err = w.notifySubscribers(tx, incident, models.SubscriberEventIncidentResolved)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("notifying user that website is up!")
+	return w.notifyUser(ctx, tx, alertConfig, incident, models.Healthy, "", 1, incident.EscalationLevel)
+}
+
+// escalateIncident notifies the next escalation level or reminds the already notified levels
+// as per the escalation policy, acknowledged incidents are not escalated any further.
+func (w *websitePickerJob) escalateIncident(ctx context.Context, tx *gorm.DB, alertConfig *models.AlertConfig, incident *models.Incident, status models.HealthStatus, reason string, now time.Time) error {
+	if incident.State != models.IncidentStateOpen {
+		return nil
+	}
+
+	var (
+		policy    = alertConfig.EscalationPolicy
+		level     = incident.EscalationLevel
+		fromLevel int
+	)
+
+	switch {
+	case policy.EscalateAfterMinutes > 0 && level < constants.MAX_ESCALATION_LEVEL &&
+		now.Sub(incident.StartedAt) >= time.Duration(policy.EscalateAfterMinutes)*time.Minute:
+		level++
+		fromLevel = level
+		logger.Info("incident not acknowledged in time, escalating to level ", level)
+	case policy.RepeatEveryMinutes > 0 &&
+		(incident.LastNotifiedAt == nil || now.Sub(*incident.LastNotifiedAt) >= time.Duration(policy.RepeatEveryMinutes)*time.Minute):
+		fromLevel = 1
+		logger.Info("incident still not acknowledged, reminding user that website is down!")
+	default:
+		return nil
 	}
 
+	incidentsRepo := models.InitIncidentsRepo(w.DB)
+	err := incidentsRepo.UpdateSelectedWithTx(tx, &models.Incident{ID: incident.ID}, &models.Incident{
+		EscalationLevel: level,
+		LastNotifiedAt:  &now,
+	}, "escalation_level", "last_notified_at")
+	if err != nil {
+		logger.Error("error in updating incident escalation | err: ", err)
+		return err
+	}
+
+	return w.notifyUser(ctx, tx, alertConfig, incident, status, reason, fromLevel, level)
 }
 
 func (w *websitePickerJob) DoHealthCheck(parentCtx context.Context, website models.Website) {
@@ -265,28 +521,72 @@ func (w *websitePickerJob) DoHealthCheck(parentCtx context.Context, website mode
 	childCtx, cancel := context.WithTimeout(parentCtx, w.config.HealthCheckTimeout)
 	defer cancel()
 
-	req, _ := http.NewRequestWithContext(childCtx, http.MethodGet, normalizeURL(website.WebsiteURL), nil)
+	checker, err := w.checkerFor(website.CheckType)
+	if err != nil {
+		logger.Error("error in picking checker for website | err: ", err)
+		return
+	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	result := checker.Check(childCtx, website)
+	if result.Err != nil {
+		logger.Error("error while checking website's health | err: ", result.Err)
+	}
 
-	start := time.Now()
-	resp, err := w.httpClient.Do(req)
-	latency := time.Since(start)
+	//check if incident should be created/already present and notify them
+	w.CreateOrResolveIncident(childCtx, website, result)
+}
 
-	if resp != nil {
-		defer resp.Body.Close()
-		_, _ = io.Copy(io.Discard, resp.Body)
+// PollWebsites keeps pushing due websites to the workers every PollInterval until ctx is cancelled
+func (w *websitePickerJob) PollWebsites(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
 
-		//check if incident should be created/already present and notify them
-		w.CreateOrResolveIncident(childCtx, website.ID, resp.StatusCode, latency)
-	}
-	if err != nil {
-		logger.Error("error while checking website's health | err: ", err)
-		return
+	for {
+		//refreshed every poll so the location keeps counting towards the incident quorum
+		w.registerLocation(ctx)
+
+		err := w.FetchWebsitesForJob(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("website fetching error: ", err)
+		}
+		w.SweepHeartbeats(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
 	}
+}
+
+// StartWorkers runs the health check workers until the websites channel is closed or ctx is cancelled
+func (w *websitePickerJob) StartWorkers(ctx context.Context) {
+	for i := 0; i < w.config.WorkerCount; i++ {
+		w.wg.Add(1)
+		go func(workerId int) {
+			defer w.wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					// r is the panic payload (error, string, etc.)
+					logger.Error("goroutine panicked | err: ", r)
+				}
+			}()
+			for {
+				select {
+				case site, ok := <-w.websitesChan:
+					if !ok {
+						// channel closed
+						return
+					}
+					w.DoHealthCheck(ctx, site)
 
+				case <-ctx.Done():
+					// job timeout or cancellation
+					return
+				}
+			}
+		}(i + 1)
+	}
 }
 
 func ProcessWebsitesJob(ctrl controllers.BaseController) {
@@ -299,6 +599,8 @@ func ProcessWebsitesJob(ctrl controllers.BaseController) {
 	ctx, cancel := context.WithTimeout(context.Background(), config.JobTimeout)
 	defer cancel()
 
+	job.registerLocation(ctx)
+
 	go func() {
 		defer job.CloseChannel()
 		defer func() {
@@ -311,93 +613,256 @@ func ProcessWebsitesJob(ctrl controllers.BaseController) {
 		if err != nil {
 			logger.Error("website fetching error: ", err)
 		}
+		job.SweepHeartbeats(ctx)
 	}()
 
-	for i := 0; i < config.WorkerCount; i++ {
-		job.wg.Add(1)
-		go func(workerId int) {
-			defer job.wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					// r is the panic payload (error, string, etc.)
-					logger.Error("goroutine panicked | err: ", r)
-				}
-			}()
-			for {
-				select {
-				case site, ok := <-job.websitesChan:
-					if !ok {
-						// channel closed
-						return
-					}
-					job.DoHealthCheck(ctx, site)
+	job.StartWorkers(ctx)
 
-				case <-ctx.Done():
-					// job timeout or cancellation
-					return
-				}
+	job.wg.Wait()
+}
+
+// RunWebsitesDaemon keeps the worker pool alive and continuously picks due websites.
+// On SIGTERM it stops picking, lets the workers finish what was already picked and
+// cancels whatever is still running after DrainTimeout.
+func RunWebsitesDaemon(ctrl controllers.BaseController) {
+	config := DefaultConfig()
+
+	job := New(jobs.JobInput{
+		BaseController: ctrl,
+	}, config)
+
+	shutdownCtx, stop := graceful.ShutdownContext(context.Background(), &graceful.ServerState{})
+	defer stop()
+
+	//workers get their own context so that in-flight checks are not cancelled with the poller
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+
+	go func() {
+		defer job.CloseChannel()
+		defer func() {
+			if r := recover(); r != nil {
+				// r is the panic payload (error, string, etc.)
+				logger.Error("goroutine panicked | err: ", r)
 			}
-		}(i + 1)
+		}()
+		job.PollWebsites(shutdownCtx)
+	}()
+
+	job.StartWorkers(workerCtx)
+
+	<-shutdownCtx.Done()
+	logger.Info("draining in-flight health checks with timeout: ", config.DrainTimeout)
+
+	drained := make(chan struct{})
+	go func() {
+		job.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(config.DrainTimeout):
+		logger.Error("drain timeout reached, cancelling in-flight health checks")
+		cancelWorkers()
+		<-drained
 	}
+}
 
-	job.wg.Wait()
+// isInMaintenance tells whether a maintenance window of the website (or of all the user's websites) covers now
+func (w *websitePickerJob) isInMaintenance(ctx context.Context, website models.Website, now time.Time) bool {
+	active, err := models.InitMaintenanceWindowsRepo(w.DB).CoversWebsite(w.DB.WithContext(ctx), website.UserId, website.ID, now)
+	if err != nil {
+		//rather alert during a window than miss an outage
+		logger.Error("error in fetching maintenance windows | err: ", err)
+		return false
+	}
+	return active
 }
 
-func (w *websitePickerJob) notifyUser(ctx context.Context, alertConfigID uint, healthStatus models.HealthStatus, websiteID uint) {
-	var (
-		alertTargetRepo    = models.InitAlertTargetRepo(w.DB.WithContext(ctx))
-		incidentEventsRepo = models.InitIncidentEventsRepo(w.DB)
-		websiteRepo        = models.InitWebsiteRepo(w.DB)
-	)
+// isNotificationSuppressed tells whether alerts are turned off or temporarily muted for the config
+func isNotificationSuppressed(alertConfig *models.AlertConfig, now time.Time) bool {
+	return !alertConfig.IsEnabled || (alertConfig.MutedUntil != nil && now.Before(*alertConfig.MutedUntil))
+}
+
+// isPhoneTargetRateLimited tells whether the sms/voice target already used up its alerts for the window
+func (w *websitePickerJob) isPhoneTargetRateLimited(tx *gorm.DB, alertTargetID uint) bool {
+	return phoneTargetRateLimited(models.InitIncidentEventsRepo(w.DB), tx, alertTargetID, time.Now())
+}
+
+// phoneTargetRateLimited counts the alerts sent to the target within the window before now
+func phoneTargetRateLimited(incidentEventsRepo models.IIncidentEvent, tx *gorm.DB, alertTargetID uint, now time.Time) bool {
+	since := now.Add(-constants.PHONE_ALERT_RATE_LIMIT_WINDOW_MINUTES * time.Minute)
+
+	count, err := incidentEventsRepo.CountSentSince(tx, alertTargetID, since)
+	if err != nil {
+		//rather over-notify than drop an alert because of a failed count
+		logger.Error("error in counting recent alerts of the target | err: ", err)
+		return false
+	}
+	return count >= constants.PHONE_ALERT_RATE_LIMIT
+}
+
+// notifyUser notifies the targets within the escalation levels [fromLevel, toLevel] about the incident, see notifyTargets
+func (w *websitePickerJob) notifyUser(ctx context.Context, tx *gorm.DB, alertConfig *models.AlertConfig, incident *models.Incident, healthStatus models.HealthStatus, reason string, fromLevel, toLevel int) error {
+	websiteRepo := models.InitWebsiteRepo(w.DB)
 
-	website, err := websiteRepo.GetWithTx(&models.Website{ID: websiteID}, w.DB.WithContext(ctx))
+	website, err := websiteRepo.GetWithTx(&models.Website{ID: incident.WebsiteId}, tx)
 	if err != nil {
 		logger.Error("error in getting the webiste with given websiteId | err: ", err)
-		return
+		return err
 	}
 
-	alertTargets, err := alertTargetRepo.GetAllByAlertConfigID(alertConfigID)
+	//down notifications carry a link to acknowledge the incident without logging in. The links are signed with a
+	//secret of their own, rotating the jwt secret must not break the ones already sent.
+	ackURL := ""
+	if healthStatus == models.Unhealthy && w.BaseController.Config.IncidentAckSecret != "" {
+		ackURL = utils.SignedIncidentAckURL(w.BaseController.Config.ServerBaseUrl, w.BaseController.Config.IncidentAckSecret, incident.ID,
+			time.Now().AddDate(0, 0, constants.INCIDENT_ACK_LINK_EXPIRY_DAYS))
+	}
+
+	return w.notifyTargets(ctx, tx, alertConfig, website, incident.ID, healthStatus, reason, ackURL, fromLevel, toLevel)
+}
+
+// notifyTargets records an incident event for every active target within the escalation levels
+// [fromLevel, toLevel] along with an outbox message to deliver it, both within the caller's transaction.
+// When notifications are suppressed the events are still recorded (as SUPPRESSED) for auditing but never queued.
+// incidentID is 0 for alerts which are not about an incident (eg. an expiring certificate).
+func (w *websitePickerJob) notifyTargets(ctx context.Context, tx *gorm.DB, alertConfig *models.AlertConfig, website *models.Website, incidentID uint, healthStatus models.HealthStatus, reason, ackURL string, fromLevel, toLevel int) error {
+	var (
+		alertTargetRepo    = models.InitAlertTargetRepo(tx)
+		incidentEventsRepo = models.InitIncidentEventsRepo(w.DB)
+		outboxMessagesRepo = models.InitOutboxMessagesRepo(w.DB)
+	)
+
+	suppressed := isNotificationSuppressed(alertConfig, time.Now())
+
+	alertTargets, err := alertTargetRepo.GetAllByAlertConfigID(alertConfig.ID)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		logger.Error("error in getting the alert targets for this config | err: ", err)
-		return
+		return err
 	}
 
 	if err == gorm.ErrRecordNotFound {
 		logger.Error("no alert targets are present for this webiste")
-		return
+		return nil
 	}
 
 	for _, target := range alertTargets {
-		if target.TargetType == models.TargetTypeSMS {
-			logger.Error("SMS notifications is not supported currently!")
-		} else {
-			incidentEventMsgForQueue := jobs.SQSIncidentEventType{
-				WebsiteURL: website.WebsiteURL,
-				Phone:      "",
-				Email:      target.TargetValue,
-				Status:     string(healthStatus),
-			}
-			incidentEvent := models.IncidentEvent{
-				HealthStatus:  string(healthStatus),
-				WebsiteURL:    website.WebsiteURL,
-				EventStatus:   models.EventStatusPending,
-				AlertTargetId: target.ID,
-			}
+		if target.EscalationLevel < fromLevel || target.EscalationLevel > toLevel {
+			continue
+		}
 
-			err := incidentEventsRepo.CreateWithTx(w.DB.WithContext(ctx), &incidentEvent)
-			if err != nil {
-				logger.Error("error in creating incident event | err: ", err)
-				return
-			}
+		incidentEventMsgForQueue := jobs.SQSIncidentEventType{
+			WebsiteURL:    website.WebsiteURL,
+			Status:        string(healthStatus),
+			Reason:        reason,
+			AckURL:        ackURL,
+			TargetType:    string(target.TargetType),
+			AlertTargetID: target.ID,
+		}
+		switch {
+		case target.TargetType.IsWebhook():
+			incidentEventMsgForQueue.WebhookURL = target.TargetValue
+		case target.TargetType.IsPhone():
+			incidentEventMsgForQueue.Phone = target.TargetValue
+		default:
+			incidentEventMsgForQueue.Email = target.TargetValue
+		}
 
-			incidentEventMsgForQueue.IncidentEventID = incidentEvent.UUID
+		//kept on the event without its id, so that retries can queue it again
+		payload, err := json.Marshal(&incidentEventMsgForQueue)
+		if err != nil {
+			logger.Error("error in marshalling incident event | err: ", err)
+			return err
+		}
 
-			err = aws.SendMessage(w.sqsClient, w.BaseController.Config.AwsQueueUrl, &incidentEventMsgForQueue)
-			if err != nil {
-				logger.Error("error in sending incident event to SQS for notification | err: ", err)
-				return
-			}
+		incidentEvent := models.IncidentEvent{
+			Payload:       string(payload),
+			HealthStatus:  string(healthStatus),
+			Reason:        reason,
+			WebsiteURL:    website.WebsiteURL,
+			EventStatus:   models.EventStatusPending,
+			AlertTargetId: target.ID,
+			IncidentId:    incidentID,
+		}
+		if suppressed {
+			incidentEvent.EventStatus = models.EventStatusSuppressed
+		} else if target.TargetType.IsPhone() && w.isPhoneTargetRateLimited(tx, target.ID) {
+			incidentEvent.EventStatus = models.EventStatusRateLimited
+		}
+
+		err = incidentEventsRepo.CreateWithTx(tx, &incidentEvent)
+		if err != nil {
+			logger.Error("error in creating incident event | err: ", err)
+			return err
+		}
+
+		if incidentEvent.EventStatus == models.EventStatusSuppressed {
+			logger.Info("notifications are disabled/muted for this website, not notifying")
+			continue
 		}
+		if incidentEvent.EventStatus == models.EventStatusRateLimited {
+			logger.Info("phone target got too many alerts recently, not notifying target: ", target.ID)
+			continue
+		}
+
+		incidentEventMsgForQueue.IncidentEventID = incidentEvent.UUID
+
+		body, err := json.Marshal(&incidentEventMsgForQueue)
+		if err != nil {
+			logger.Error("error in marshalling incident event | err: ", err)
+			return err
+		}
+
+		err = outboxMessagesRepo.CreateWithTx(tx, &models.OutboxMessage{Payload: string(body)})
+		if err != nil {
+			logger.Error("error in creating outbox message for notification | err: ", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifySubscribers records an update for every status page showing the website along with an outbox message
+// which starts its fan-out to the page's subscribers, within the caller's transaction. Muting alerts does not
+// apply here, subscribers follow the public state of the website.
+func (w *websitePickerJob) notifySubscribers(tx *gorm.DB, incident *models.Incident, event models.SubscriberEvent) error {
+	var (
+		statusPagesRepo             = models.InitStatusPagesRepo(w.DB)
+		subscriberNotificationsRepo = models.InitSubscriberNotificationsRepo(w.DB)
+		outboxMessagesRepo          = models.InitOutboxMessagesRepo(w.DB)
+	)
+
+	entries, err := statusPagesRepo.ListEntriesByWebsiteID(tx, incident.WebsiteId)
+	if err != nil {
+		return err
 	}
 
+	for _, entry := range entries {
+		notification := models.SubscriberNotification{
+			StatusPageId: entry.StatusPageId,
+			IncidentId:   incident.ID,
+			Event:        event,
+			Component:    entry.DisplayName,
+		}
+		err := subscriberNotificationsRepo.CreateWithTx(tx, &notification)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(&jobs.SubscriberNotificationMessage{SubscriberNotificationID: notification.UUID})
+		if err != nil {
+			logger.Error("error in marshalling subscriber notification | err: ", err)
+			return err
+		}
+
+		err = outboxMessagesRepo.CreateWithTx(tx, &models.OutboxMessage{Payload: string(body), Topic: models.OutboxTopicSubscriberNotifications})
+		if err != nil {
+			logger.Error("error in creating outbox message for subscribers | err: ", err)
+			return err
+		}
+	}
+	return nil
 }
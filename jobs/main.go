@@ -1,14 +1,40 @@
 package jobs
 
-import controllers "github.com/ankur12345678/uptime-monitor/Controllers"
+import (
+	controllers "github.com/ankur12345678/uptime-monitor/Controllers"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/queue"
+)
 
 type JobName string
 
 const (
-	MonitorWesbitesJob JobName = "monitor-websites"
-	NotificationJob    JobName = "notify-users"
+	MonitorWesbitesJob       JobName = "monitor-websites"
+	MonitorWebsitesDaemonJob JobName = "monitor-websites-daemon"
+	NotificationJob          JobName = "notify-users"
+	OutboxRelayJob           JobName = "outbox-relay"
 )
 
 type JobInput struct {
 	controllers.BaseController
+	// incident events flow from the outbox relay to the notification job through it,
+	// only set for the jobs which use it (see NewJobInput)
+	Queue queue.Queue
+	// same for the status page updates fanned out to subscribers
+	SubscriberQueue queue.Queue
+}
+
+// NewJobInput builds the input for a job along with the queues selected in the config
+func NewJobInput(ctrl controllers.BaseController) JobInput {
+	q, err := queue.New(ctrl.Config, ctrl.DB, ctrl.RedisClient)
+	if err != nil {
+		logger.Fatal("unable to init queue | err: ", err)
+	}
+
+	subscriberQueue, err := queue.NewSubscriberQueue(ctrl.Config, ctrl.DB, ctrl.RedisClient)
+	if err != nil {
+		logger.Fatal("unable to init subscriber queue | err: ", err)
+	}
+
+	return JobInput{BaseController: ctrl, Queue: q, SubscriberQueue: subscriberQueue}
 }
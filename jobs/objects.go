@@ -1,9 +1,28 @@
 package jobs
 
+import "fmt"
+
 type SQSIncidentEventType struct {
-	WebsiteURL      string `json:"website_url"`
-	Phone           string `json:"phone_number"`
-	Email           string `json:"email"`
+	WebsiteURL string `json:"website_url"`
+	Phone      string `json:"phone_number"`
+	Email      string `json:"email"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	//empty for messages queued before the target types were added, those are emails
+	TargetType      string `json:"target_type,omitempty"`
+	AlertTargetID   uint   `json:"alert_target_id,omitempty"`
 	Status          string `json:"status"`
+	Reason          string `json:"reason,omitempty"`
 	IncidentEventID string `json:"incident_event_id"`
+	AckURL          string `json:"ack_url,omitempty"`
+}
+
+// String identifies the message in logs. Target addresses, webhook urls and the signed ack url are
+// secrets of their own and are left out.
+func (m SQSIncidentEventType) String() string {
+	return fmt.Sprintf("incident_event_id: %s, target_type: %s, alert_target_id: %d", m.IncidentEventID, m.TargetType, m.AlertTargetID)
+}
+
+// SubscriberNotificationMessage starts (or carries on) the fan-out of a models.SubscriberNotification
+type SubscriberNotificationMessage struct {
+	SubscriberNotificationID string `json:"subscriber_notification_id"`
 }
@@ -11,6 +11,7 @@ import (
 	Router "github.com/ankur12345678/uptime-monitor/Router"
 	"github.com/ankur12345678/uptime-monitor/jobs"
 	notification "github.com/ankur12345678/uptime-monitor/jobs/Notification"
+	outboxrelay "github.com/ankur12345678/uptime-monitor/jobs/OutboxRelay"
 	websitepicker "github.com/ankur12345678/uptime-monitor/jobs/WebsitePicker"
 	"github.com/ankur12345678/uptime-monitor/pkg/graceful"
 	"github.com/ankur12345678/uptime-monitor/pkg/logger"
@@ -34,6 +35,8 @@ func main() {
 	//init redis client
 	redisClient := migration.InitRedisClient(ctrl.Config)
 	controllers.Ctrl.RedisClient = redisClient
+	//jobs need it as well, eg. for the redis queue driver
+	ctrl.RedisClient = redisClient
 
 	//seeding data for test
 	migration.SeedDB(db)
@@ -47,6 +50,14 @@ func main() {
 		logger.Infof("****** Starting Job: %s ******", job)
 		websitepicker.ProcessWebsitesJob(ctrl)
 		logger.Infof("****** Completed Job: %s ******", job)
+	case jobs.MonitorWebsitesDaemonJob:
+		logger.Infof("****** Starting Job: %s ******", job)
+		websitepicker.RunWebsitesDaemon(ctrl)
+		logger.Infof("****** Completed Job: %s ******", job)
+	case jobs.OutboxRelayJob:
+		logger.Infof("****** Starting Job: %s ******", job)
+		outboxrelay.RunOutboxRelay(ctrl)
+		logger.Infof("****** Completed Job: %s ******", job)
 	case jobs.NotificationJob:
 		logger.Infof("****** Starting Job: %s ******", job)
 		notification.Start(&ctrl)
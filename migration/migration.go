@@ -19,7 +19,7 @@ func InitDB(cfg *config.Creds) *gorm.DB {
 	if err != nil {
 		panic("Error connecting DB...Exiting!")
 	}
-	db.AutoMigrate(&models.User{}, &models.Website{}, &models.AlertConfig{}, &models.Log{}, &models.Incident{}, &models.AlertTarget{}, &models.IncidentEvent{})
+	db.AutoMigrate(&models.User{}, &models.Website{}, &models.AlertConfig{}, &models.Log{}, &models.Incident{}, &models.AlertTarget{}, &models.IncidentEvent{}, &models.QueueMessage{}, &models.OutboxMessage{}, &models.ProbeLocation{}, &models.WebsiteLocationCheck{}, &models.MaintenanceWindow{}, &models.MaintenanceWindowWebsite{}, &models.StatusPage{}, &models.StatusPageWebsite{}, &models.StatusPageSubscriber{}, &models.SubscriberNotification{}, &models.HeartbeatPing{}, &models.StepLog{})
 	logger.Info("Connected to DB!")
 	return db
 }
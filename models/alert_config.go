@@ -7,6 +7,36 @@ import (
 	"gorm.io/gorm"
 )
 
+type AssertionType string
+
+const (
+	AssertionTypeStatusCode   AssertionType = "status_code"
+	AssertionTypeBodyContains AssertionType = "body_contains"
+	AssertionTypeBodyRegex    AssertionType = "body_regex"
+	AssertionTypeJSONPath     AssertionType = "json_path"
+	AssertionTypeHeader       AssertionType = "header"
+	AssertionTypeMaxBodySize  AssertionType = "max_body_size"
+)
+
+// Assertion is a single rule evaluated against the response of a check.
+// Property holds the header name / json path, Value holds the expectation
+// (eg. "200,201,300-399" for status codes or "1048576" for max body size in bytes).
+// An empty Value on a header assertion only checks for its presence.
+type Assertion struct {
+	Type     AssertionType `json:"type"`
+	Property string        `json:"property,omitempty"`
+	Value    string        `json:"value,omitempty"`
+}
+
+// EscalationPolicy decides who is notified while an incident is not acknowledged.
+// Level-1 targets are notified as soon as the incident opens, level-2 targets after
+// EscalateAfterMinutes (0 never escalates) and every notified level is reminded each
+// RepeatEveryMinutes (0 never reminds).
+type EscalationPolicy struct {
+	EscalateAfterMinutes int `gorm:"not null;default:0" json:"escalate_after_minutes"`
+	RepeatEveryMinutes   int `gorm:"not null;default:0" json:"repeat_every_minutes"`
+}
+
 type AlertConfig struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -14,11 +44,21 @@ type AlertConfig struct {
 
 	WebsiteID        uint `gorm:"not null;index" json:"website_id"`
 	FailureThreshold int  `gorm:"not null;default:3" json:"failure_threshold"`
-	LatencyThreshold int  `gorm:"not null;default:5000" json:"latency_threshold"`
+	LatencyThreshold int  `gorm:"not null;default:5000" json:"latency_threshold"` //0 disables the latency check
+	//an incident is opened once this many probe locations see FailureThreshold failed checks in a row
+	LocationQuorum int `gorm:"not null;default:1" json:"location_quorum"`
+	//https/tls monitors alert once their certificate expires within this many days, 0 disables the alert
+	CertExpiryAlertDays int `gorm:"not null;default:14" json:"cert_expiry_alert_days"`
 
 	IsEnabled bool `gorm:"default:false" json:"is_enabled"`
+	//notifications are suppressed until this time even if the config is enabled
+	MutedUntil *time.Time `json:"muted_until"`
+
+	Assertions []Assertion `gorm:"serializer:json" json:"assertions"`
+
+	EscalationPolicy EscalationPolicy `gorm:"embedded;embeddedPrefix:escalation_" json:"escalation_policy"`
 
-	Website Website `gorm:"foreignKey:WebsiteID;References:ID"`
+	Website Website `gorm:"foreignKey:WebsiteID;References:ID" json:"-"`
 }
 
 type alertConfigRepo struct {
@@ -59,6 +99,19 @@ func (acr *alertConfigRepo) UpdateWithTx(tx *gorm.DB, where *AlertConfig, a *Ale
 	return nil
 }
 
+// UpdateSelectedWithTx implements IAlertConfig.
+// Only the given columns are updated, which unlike UpdateWithTx also allows setting zero values.
+func (acr *alertConfigRepo) UpdateSelectedWithTx(tx *gorm.DB, where *AlertConfig, a *AlertConfig, columns ...string) error {
+	err := tx.
+		Model(&AlertConfig{}).
+		Where(where).Select(columns).Updates(a).Error
+	if err != nil {
+		logger.Error("unable to update alert config | err: ", err)
+		return err
+	}
+	return nil
+}
+
 // Delete implements IAlertConfig.
 func (acr *alertConfigRepo) Delete(where *AlertConfig) error {
 	return acr.DeleteWithTx(acr.db, where)
@@ -12,8 +12,26 @@ type TargetType string
 const (
 	TargetTypeSMS   TargetType = "sms"
 	TargetTypeEmail TargetType = "email"
+	//incoming webhook url of the channel is the TargetValue
+	TargetTypeSlack   TargetType = "slack"
+	TargetTypeDiscord TargetType = "discord"
+	TargetTypeTeams   TargetType = "teams"
+	//generic json webhook signed with the target's signing secret
+	TargetTypeWebhook TargetType = "webhook"
+	//E.164 phone number is the TargetValue
+	TargetTypeVoice TargetType = "voice"
 )
 
+// IsPhone tells whether the target is delivered through the telephony provider
+func (t TargetType) IsPhone() bool {
+	return t == TargetTypeSMS || t == TargetTypeVoice
+}
+
+// IsWebhook tells whether the target is delivered by posting to the TargetValue url
+func (t TargetType) IsWebhook() bool {
+	return t == TargetTypeSlack || t == TargetTypeDiscord || t == TargetTypeTeams || t == TargetTypeWebhook
+}
+
 type AlertTarget struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -21,7 +39,17 @@ type AlertTarget struct {
 
 	TargetType  TargetType `gorm:"not null" json:"target_type"`
 	TargetValue string     `gorm:"not null" json:"target_value"`
-	IsActive    bool       `gorm:"default:true" json:"is_active"`
+	IsActive    bool       `gorm:"default:false" json:"is_active"`
+	//see EscalationPolicy
+	EscalationLevel int `gorm:"not null;default:1" json:"escalation_level"`
+
+	//email targets stay inactive until the link with this token is opened
+	VerificationToken  string     `gorm:"index:idx_alert_target_verification_token" json:"-"`
+	VerificationSentAt *time.Time `json:"-"`
+	VerifiedAt         *time.Time `json:"verified_at"`
+
+	//encrypted with Config.EncryptionKey, only set for webhook targets
+	EncryptedSigningSecret string `json:"-"`
 
 	AlertConfigID uint `gorm:"not null;index" json:"alert_config_id"`
 }
@@ -37,7 +65,7 @@ func (atr *alertTargetRepo) Create(at *AlertTarget) error {
 
 // CreateWithTx implements IAlertTarget.
 func (atr *alertTargetRepo) CreateWithTx(tx *gorm.DB, at *AlertTarget) error {
-	return tx.Model(&AlertTarget{}).Create(&atr).Error
+	return tx.Model(&AlertTarget{}).Create(&at).Error
 }
 
 // GetWithTx implements IAlertTarget.
@@ -58,7 +86,20 @@ func (atr *alertTargetRepo) UpdateWithTx(tx *gorm.DB, where *AlertTarget, a *Ale
 		Model(&AlertTarget{}).
 		Where(where).Updates(&a).Error
 	if err != nil {
-		logger.Error("unable to update alert config | err: ", err)
+		logger.Error("unable to update alert target | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateSelectedWithTx implements IAlertTarget.
+// Only the given columns are updated, which unlike UpdateWithTx also allows setting zero values.
+func (atr *alertTargetRepo) UpdateSelectedWithTx(tx *gorm.DB, where *AlertTarget, a *AlertTarget, columns ...string) error {
+	err := tx.
+		Model(&AlertTarget{}).
+		Where(where).Select(columns).Updates(a).Error
+	if err != nil {
+		logger.Error("unable to update alert target | err: ", err)
 		return err
 	}
 	return nil
@@ -94,3 +135,19 @@ func (atr *alertTargetRepo) GetAllByAlertConfigID(alertConfigID uint) ([]AlertTa
 	}
 	return targets, nil
 }
+
+// ListByAlertConfigID returns every target of the config, including the inactive/unverified ones
+func (atr *alertTargetRepo) ListByAlertConfigID(alertConfigID uint) ([]AlertTarget, error) {
+	var targets []AlertTarget
+	err := atr.db.
+		Model(&AlertTarget{}).
+		Where("alert_config_id = ?", alertConfigID).
+		Order("id").
+		Find(&targets).Error
+
+	if err != nil {
+		logger.Error("error in listing alert targets by alert config id | err: ", err)
+		return nil, err
+	}
+	return targets, nil
+}
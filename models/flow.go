@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+type ExtractionSource string
+
+const (
+	ExtractionSourceJSONPath ExtractionSource = "json_path"
+	//the first capture group of the regex, or the whole match when it has none
+	ExtractionSourceRegex  ExtractionSource = "regex"
+	ExtractionSourceHeader ExtractionSource = "header"
+)
+
+// FlowExtraction stores a value of a step's response in Variable, Property holds the json path/regex/header name
+type FlowExtraction struct {
+	Variable string           `json:"variable"`
+	Source   ExtractionSource `json:"source"`
+	Property string           `json:"property"`
+}
+
+// FlowStep is a single request of a flow check. The url, header values and body may refer to the variables
+// extracted by earlier steps as {{name}}, the values are inserted as is.
+type FlowStep struct {
+	Name    string            `json:"name"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+
+	Extract []FlowExtraction `json:"extract,omitempty"`
+	//a status code assertion replaces the default 4xx/5xx evaluation of the step
+	Assertions []Assertion `json:"assertions,omitempty"`
+}
+
+// StepLog is the outcome of a single step of a flow check, kept alongside the Log of the check
+type StepLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	LogId     uint   `gorm:"not null;index" json:"log_id"`
+	WebsiteId uint   `gorm:"not null;index" json:"-"`
+	Position  int    `gorm:"not null" json:"position"`
+	Name      string `gorm:"not null" json:"name"`
+
+	StatusCode    uint   `gorm:"not null" json:"status_code"`
+	LatencyInMS   uint   `gorm:"not null" json:"latency_in_ms"`
+	HealthStatus  string `gorm:"not null" json:"health_status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"gorm.io/gorm"
+)
+
+type HeartbeatSignal string
+
+const (
+	//a run started, it has to finish within the grace time of the heartbeat
+	HeartbeatSignalStart   HeartbeatSignal = "start"
+	HeartbeatSignalSuccess HeartbeatSignal = "success"
+	HeartbeatSignalFail    HeartbeatSignal = "fail"
+)
+
+// HeartbeatLocation is the location of the logs of heartbeats, they are not checked from any probe location
+const HeartbeatLocation = "heartbeat"
+
+// HeartbeatPing is a single ping of a heartbeat monitor, as sent by the monitored job
+type HeartbeatPing struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index:idx_heartbeat_ping_website_created_at,sort:desc" json:"created_at"`
+
+	WebsiteId uint            `gorm:"not null;index:idx_heartbeat_ping_website_created_at" json:"-"`
+	Signal    HeartbeatSignal `gorm:"not null" json:"signal"`
+	ExitCode  *int            `json:"exit_code"`
+	//time since the start signal of the run, when it sent one
+	DurationInMS *int64 `json:"duration_in_ms"`
+	//start of the body of the ping, eg. the tail of the job's output
+	Excerpt  string `json:"excerpt,omitempty"`
+	SourceIP string `json:"source_ip"`
+}
+
+// FailureReason is the reason of the incidents opened by a failed run
+func (hp *HeartbeatPing) FailureReason() string {
+	if hp.ExitCode != nil {
+		return fmt.Sprintf("job reported a failure with exit code %d", *hp.ExitCode)
+	}
+	return "job reported a failure"
+}
+
+type heartbeatPingsRepo struct {
+	db *gorm.DB
+}
+
+func (hpr *heartbeatPingsRepo) CreateWithTx(tx *gorm.DB, hp *HeartbeatPing) error {
+	err := tx.Create(hp).Error
+	if err != nil {
+		logger.Error("error in creating heartbeat ping | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// GetLatestWithTx returns the latest ping of the website with the given signal
+func (hpr *heartbeatPingsRepo) GetLatestWithTx(tx *gorm.DB, websiteID uint, signal HeartbeatSignal) (*HeartbeatPing, error) {
+	var hp HeartbeatPing
+	err := tx.Model(&HeartbeatPing{}).
+		Where("website_id = ? AND signal = ?", websiteID, signal).
+		Order("created_at DESC").
+		First(&hp).Error
+	return &hp, err
+}
+
+// ListByWebsiteID returns the pings of the website, latest first, along with the total count
+func (hpr *heartbeatPingsRepo) ListByWebsiteID(ctx context.Context, websiteID uint, limit, offset int) ([]HeartbeatPing, int64, error) {
+	var (
+		pings []HeartbeatPing
+		total int64
+	)
+
+	query := hpr.db.WithContext(ctx).Model(&HeartbeatPing{}).Where("website_id = ?", websiteID).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting heartbeat pings | err: ", err)
+		return nil, 0, err
+	}
+
+	err = query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&pings).Error
+	if err != nil {
+		logger.Error("error in listing heartbeat pings | err: ", err)
+		return nil, 0, err
+	}
+	return pings, total, nil
+}
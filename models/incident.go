@@ -1,12 +1,22 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"github.com/ankur12345678/uptime-monitor/pkg/logger"
 	"gorm.io/gorm"
 )
 
+type IncidentState string
+
+// an incident moves open -> acknowledged -> resolved, acknowledging is optional
+const (
+	IncidentStateOpen         IncidentState = "open"
+	IncidentStateAcknowledged IncidentState = "acknowledged"
+	IncidentStateResolved     IncidentState = "resolved"
+)
+
 type Incident struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time      `json:"created_at"`
@@ -14,14 +24,33 @@ type Incident struct {
 
 	WebsiteId    uint   `gorm:"not null;index:idx_website_id" json:"website_id"`
 	HealthStatus string `gorm:"not null" json:"health_status"`
+
+	State             IncidentState `gorm:"not null;default:open;index:idx_incident_state" json:"state"`
+	StartedAt         time.Time     `gorm:"not null;default:CURRENT_TIMESTAMP" json:"started_at"`
+	AcknowledgedAt    *time.Time    `json:"acknowledged_at"`
+	ResolvedAt        *time.Time    `json:"resolved_at"`
+	DurationInSeconds int64         `json:"duration_in_seconds"` //set once resolved
+	AcknowledgedBy    string        `json:"acknowledged_by,omitempty"`
+
+	//highest escalation level notified so far and when anyone was last notified
+	EscalationLevel int        `gorm:"not null;default:1" json:"escalation_level"`
+	LastNotifiedAt  *time.Time `json:"last_notified_at"`
+
+	//root cause, refreshed on every failing check while the incident is not resolved
+	FailureReason    string `json:"failure_reason,omitempty"`
+	LastStatusCode   uint   `json:"last_status_code"`
+	ErrorText        string `json:"error_text,omitempty"`
+	FailingAssertion string `json:"failing_assertion,omitempty"`
+	//name of the failing step of flow checks
+	FailingStep string `json:"failing_step,omitempty"`
 }
 
 type incidentsRepo struct {
 	db *gorm.DB
 }
 
-func (ir *incidentsRepo) Create(tx *gorm.DB, log Incident) error {
-	err := tx.Create(&log).Error
+func (ir *incidentsRepo) Create(tx *gorm.DB, incident *Incident) error {
+	err := tx.Create(incident).Error
 	if err != nil {
 		logger.Error("error in creating log entry | err: ", err)
 		return err
@@ -35,6 +64,44 @@ func (ir *incidentsRepo) GetWithTx(tx *gorm.DB, where *Incident) (*Incident, err
 	return &incident, err
 }
 
+// GetUnresolvedByWebsiteID returns the open/acknowledged incident of the website
+func (ir *incidentsRepo) GetUnresolvedByWebsiteID(tx *gorm.DB, websiteID uint) (*Incident, error) {
+	var incident Incident
+	err := tx.Model(&Incident{}).
+		Where("website_id = ? AND state <> ?", websiteID, IncidentStateResolved).
+		Order("started_at DESC").
+		First(&incident).Error
+	return &incident, err
+}
+
+// UpdateSelectedWithTx updates only the given columns, which also allows setting zero values.
+func (ir *incidentsRepo) UpdateSelectedWithTx(tx *gorm.DB, where *Incident, i *Incident, columns ...string) error {
+	err := tx.Model(&Incident{}).
+		Where(where).Select(columns).Updates(i).Error
+	if err != nil {
+		logger.Error("error in updating Incident | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// Acknowledge moves an open incident to acknowledged, it returns false if the incident was not open
+func (ir *incidentsRepo) Acknowledge(tx *gorm.DB, incidentID uint, acknowledgedBy string) (bool, error) {
+	now := time.Now()
+	result := tx.Model(&Incident{}).
+		Where("id = ? AND state = ?", incidentID, IncidentStateOpen).
+		Updates(map[string]interface{}{
+			"state":           IncidentStateAcknowledged,
+			"acknowledged_at": now,
+			"acknowledged_by": acknowledgedBy,
+		})
+	if result.Error != nil {
+		logger.Error("error in acknowledging Incident | err: ", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 func (ir *incidentsRepo) DeleteWithTx(tx *gorm.DB, where *Incident) error {
 	err := ir.db.Model(&Incident{}).
 		Where(where).
@@ -45,3 +112,46 @@ func (ir *incidentsRepo) DeleteWithTx(tx *gorm.DB, where *Incident) error {
 	}
 	return nil
 }
+
+// ListByWebsiteID returns the incidents of the website, latest first, along with the total count
+func (ir *incidentsRepo) ListByWebsiteID(ctx context.Context, websiteID uint, limit, offset int) ([]Incident, int64, error) {
+	var (
+		incidents []Incident
+		total     int64
+	)
+
+	query := ir.db.WithContext(ctx).Model(&Incident{}).Where("website_id = ?", websiteID).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting incidents | err: ", err)
+		return nil, 0, err
+	}
+
+	err = query.Order("started_at DESC").Limit(limit).Offset(offset).Find(&incidents).Error
+	if err != nil {
+		logger.Error("error in listing incidents | err: ", err)
+		return nil, 0, err
+	}
+	return incidents, total, nil
+}
+
+// ListRecentByWebsiteIDs returns the incidents of the websites which are unresolved or started since the given
+// time, unresolved ones first and then latest first
+func (ir *incidentsRepo) ListRecentByWebsiteIDs(ctx context.Context, websiteIDs []uint, since time.Time, limit int) ([]Incident, error) {
+	var incidents []Incident
+	if len(websiteIDs) == 0 {
+		return incidents, nil
+	}
+
+	err := ir.db.WithContext(ctx).Model(&Incident{}).
+		Where("website_id IN ? AND (state <> ? OR started_at >= ?)", websiteIDs, IncidentStateResolved, since).
+		Order("state = 'resolved', started_at DESC").
+		Limit(limit).
+		Find(&incidents).Error
+	if err != nil {
+		logger.Error("error in listing recent incidents | err: ", err)
+		return nil, err
+	}
+	return incidents, nil
+}
@@ -1,11 +1,14 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
 	"github.com/ankur12345678/uptime-monitor/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type EventStatus string
@@ -14,6 +17,12 @@ const (
 	EventStatusPending   EventStatus = "PENDING"
 	EventStatusFailed    EventStatus = "FAILED"
 	EventStatusDelivered EventStatus = "DELIVERED"
+	//recorded but never sent since the alert config was disabled/muted
+	EventStatusSuppressed EventStatus = "SUPPRESSED"
+	//recorded but never sent since the target already got too many alerts recently
+	EventStatusRateLimited EventStatus = "RATE_LIMITED"
+	//delivery failed MaxAttempts times, only replayed by an admin
+	EventStatusDeadLetter EventStatus = "DEAD_LETTER"
 )
 
 type IncidentEvent struct {
@@ -22,12 +31,22 @@ type IncidentEvent struct {
 
 	UUID         string      `gorm:"not null;index:idx_incident_event_uuid" json:"uuid"`
 	HealthStatus string      `gorm:"not null" json:"health_status"`
+	Reason       string      `json:"reason,omitempty"`
 	WebsiteURL   string      `gorm:"not null" json:"website_url"`
 	EventStatus  EventStatus `gorm:"not null" json:"event_status"`
 
+	//delivery attempts, a FAILED event is queued again at NextAttemptAt
+	AttemptCount  int        `gorm:"not null;default:0" json:"attempt_count"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `gorm:"index:idx_incident_event_next_attempt_at" json:"next_attempt_at"`
+	//the queued message, kept to queue it again on retries/replays
+	Payload string `json:"-"`
+
 	AlertTargetId uint `gorm:"not null" json:"alert_target_id"`
+	IncidentId    uint `gorm:"index:idx_incident_event_incident_id" json:"incident_id"`
 
-	AlertTarget AlertTarget `gorm:"foreignKey:AlertTargetId;references:ID" json:"alert_target"`
+	//only loaded by ListByStatus
+	AlertTarget *AlertTarget `gorm:"foreignKey:AlertTargetId;references:ID" json:"alert_target,omitempty"`
 }
 
 type incidentEventsRepo struct {
@@ -50,6 +69,56 @@ func (r *incidentEventsRepo) GetWithTx(tx *gorm.DB, where *IncidentEvent) (*Inci
 	return &incidentEvent, err
 }
 
+func (ir *incidentEventsRepo) UpdateSelectedWithTx(tx *gorm.DB, where *IncidentEvent, i *IncidentEvent, columns ...string) error {
+	err := tx.Model(&IncidentEvent{}).
+		Where(where).Select(columns).Updates(i).Error
+	if err != nil {
+		logger.Error("error in updating IncidentEvent | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// ClaimDueRetries locks upto limit FAILED events whose next attempt is due, concurrent
+// callers skip each other's rows so an event is only queued again once
+func (ir *incidentEventsRepo) ClaimDueRetries(tx *gorm.DB, now time.Time, limit int) ([]IncidentEvent, error) {
+	var events []IncidentEvent
+	err := tx.Model(&IncidentEvent{}).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("event_status = ? AND next_attempt_at <= ?", EventStatusFailed, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		logger.Error("error in fetching due retries | err: ", err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// ListByStatus returns the events in any of the statuses, latest first, along with the total count
+func (ir *incidentEventsRepo) ListByStatus(ctx context.Context, statuses []EventStatus, limit, offset int) ([]IncidentEvent, int64, error) {
+	var (
+		events []IncidentEvent
+		total  int64
+	)
+
+	query := ir.db.WithContext(ctx).Model(&IncidentEvent{}).Where("event_status IN ?", statuses).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting incident events | err: ", err)
+		return nil, 0, err
+	}
+
+	err = query.Preload("AlertTarget").Order("id DESC").Limit(limit).Offset(offset).Find(&events).Error
+	if err != nil {
+		logger.Error("error in listing incident events | err: ", err)
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
 func (ir *incidentEventsRepo) UpdateWithTx(tx *gorm.DB, where *IncidentEvent, updates *IncidentEvent) error {
 	updateMap := map[string]interface{}{
 		"event_status":  updates.EventStatus,
@@ -60,3 +129,13 @@ func (ir *incidentEventsRepo) UpdateWithTx(tx *gorm.DB, where *IncidentEvent, up
 		Where("uuid = ?", where.UUID). // Make sure you're targeting the right row
 		Updates(updateMap).Error
 }
+
+// CountSentSince counts the events of the alert target which were (or are being) sent after since
+func (ir *incidentEventsRepo) CountSentSince(tx *gorm.DB, alertTargetID uint, since time.Time) (int64, error) {
+	var count int64
+	err := tx.Model(&IncidentEvent{}).
+		Where("alert_target_id = ? AND created_at > ? AND event_status IN ?", alertTargetID, since,
+			[]EventStatus{EventStatusPending, EventStatusDelivered, EventStatusFailed}).
+		Count(&count).Error
+	return count, err
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -24,9 +25,14 @@ type IWebiste interface {
 	GetWithTx(where *Website, tx *gorm.DB) (*Website, error)
 	Update(where *Website, w *Website) error
 	UpdateWithTx(tx *gorm.DB, where *Website, w *Website) error
+	UpdateSelectedWithTx(tx *gorm.DB, where *Website, w *Website, columns ...string) error
 	Delete(where *Website) error
 	DeleteWithTx(tx *gorm.DB, where *Website) error
-	FetchWebsitesInBulk(ctx context.Context, limit int) ([]Website, *gorm.DB, error)
+	FetchWebsitesInBulk(ctx context.Context, location string, limit int) ([]Website, *gorm.DB, error)
+	FetchDueHeartbeatIDs(ctx context.Context, limit int) ([]uint, error)
+	LockWithTx(tx *gorm.DB, id uint) error
+	MarkCertificateAlerted(tx *gorm.DB, id uint, expiresAt time.Time) (bool, error)
+	FetchWebsitesWithStatus(ctx context.Context, filter WebsiteFilter) ([]WebsiteWithStatus, int64, error)
 }
 
 type IAlertConfig interface {
@@ -35,6 +41,7 @@ type IAlertConfig interface {
 	GetWithTx(tx *gorm.DB, where *AlertConfig) (*AlertConfig, error)
 	Update(where *AlertConfig, a *AlertConfig) error
 	UpdateWithTx(tx *gorm.DB, where *AlertConfig, a *AlertConfig) error
+	UpdateSelectedWithTx(tx *gorm.DB, where *AlertConfig, a *AlertConfig, columns ...string) error
 	Delete(where *AlertConfig) error
 	DeleteWithTx(tx *gorm.DB, where *AlertConfig) error
 }
@@ -45,24 +52,106 @@ type IAlertTarget interface {
 	GetWithTx(tx *gorm.DB, where *AlertTarget) (*AlertTarget, error)
 	Update(where *AlertTarget, a *AlertTarget) error
 	UpdateWithTx(tx *gorm.DB, where *AlertTarget, a *AlertTarget) error
+	UpdateSelectedWithTx(tx *gorm.DB, where *AlertTarget, a *AlertTarget, columns ...string) error
 	Delete(where *AlertTarget) error
 	DeleteWithTx(tx *gorm.DB, where *AlertTarget) error
 	GetAllByAlertConfigID(alertConfigID uint) ([]AlertTarget, error)
+	ListByAlertConfigID(alertConfigID uint) ([]AlertTarget, error)
 }
 
 type ILog interface {
 	Create(ctx context.Context, log Log) error
+	CreateWithTx(tx *gorm.DB, log Log) error
+	CreateWithSteps(ctx context.Context, log Log, steps []StepLog) error
+	ListStepLogsByWebsiteID(ctx context.Context, websiteID uint, limit, offset int) ([]StepLog, int64, error)
 	FetchPastRecordStatusByWebsiteID(ctx context.Context, limit uint, webisteID uint) ([]string, error)
+	FetchStatsByWebsiteID(ctx context.Context, websiteID uint, from, to time.Time) (*LogStats, error)
+	FetchStatsSeriesByWebsiteID(ctx context.Context, websiteID uint, from, to time.Time, bucket string) ([]LogStatsBucket, error)
+	FetchFailedStatusCodesByWebsiteID(ctx context.Context, websiteID uint, from, to time.Time) ([]StatusCodeCount, error)
+	FetchRecentStatusesByLocation(ctx context.Context, websiteID uint, since time.Time, limit int) ([]LocationHealthStatus, error)
+	FetchStatsByLocation(ctx context.Context, websiteID uint, from, to time.Time) ([]LocationLogStats, error)
+	FetchDailyUptimeByWebsiteIDs(ctx context.Context, websiteIDs []uint, from time.Time) ([]DailyUptime, error)
 }
 
 type IIncident interface {
-	Create(tx *gorm.DB, log Incident) error
+	Create(tx *gorm.DB, incident *Incident) error
 	GetWithTx(tx *gorm.DB, where *Incident) (*Incident, error)
+	GetUnresolvedByWebsiteID(tx *gorm.DB, websiteID uint) (*Incident, error)
+	UpdateSelectedWithTx(tx *gorm.DB, where *Incident, i *Incident, columns ...string) error
+	Acknowledge(tx *gorm.DB, incidentID uint, acknowledgedBy string) (bool, error)
 	DeleteWithTx(tx *gorm.DB, where *Incident) error
+	ListByWebsiteID(ctx context.Context, websiteID uint, limit, offset int) ([]Incident, int64, error)
+	ListRecentByWebsiteIDs(ctx context.Context, websiteIDs []uint, since time.Time, limit int) ([]Incident, error)
 }
 
 type IIncidentEvent interface {
 	CreateWithTx(tx *gorm.DB, i *IncidentEvent) error
 	GetWithTx(tx *gorm.DB, where *IncidentEvent) (*IncidentEvent, error)
 	UpdateWithTx(tx *gorm.DB, where *IncidentEvent, i *IncidentEvent) error
+	CountSentSince(tx *gorm.DB, alertTargetID uint, since time.Time) (int64, error)
+	UpdateSelectedWithTx(tx *gorm.DB, where *IncidentEvent, i *IncidentEvent, columns ...string) error
+	ClaimDueRetries(tx *gorm.DB, now time.Time, limit int) ([]IncidentEvent, error)
+	ListByStatus(ctx context.Context, statuses []EventStatus, limit, offset int) ([]IncidentEvent, int64, error)
+}
+
+type IQueueMessage interface {
+	CreateWithTx(tx *gorm.DB, m *QueueMessage) error
+	ClaimBatch(tx *gorm.DB, queueName string, visibilityTimeout time.Duration, limit int) ([]QueueMessage, error)
+	ExtendVisibilityWithTx(tx *gorm.DB, id uint, visibilityTimeout time.Duration) error
+	DeleteWithTx(tx *gorm.DB, where *QueueMessage) error
+	DeleteByIDsWithTx(tx *gorm.DB, ids []uint) error
+}
+
+type IOutboxMessage interface {
+	CreateWithTx(tx *gorm.DB, m *OutboxMessage) error
+	ClaimUnsent(tx *gorm.DB, limit int, now time.Time) ([]OutboxMessage, error)
+	UpdateSelectedWithTx(tx *gorm.DB, where *OutboxMessage, m *OutboxMessage, columns ...string) error
+	DeleteSentBefore(tx *gorm.DB, before time.Time) (int64, error)
+}
+
+type IProbeLocation interface {
+	Register(tx *gorm.DB, name string) error
+	CountActiveSince(tx *gorm.DB, since time.Time) (int64, error)
+}
+
+type IMaintenanceWindow interface {
+	CreateWithTx(tx *gorm.DB, mw *MaintenanceWindow) error
+	GetWithTx(tx *gorm.DB, where *MaintenanceWindow) (*MaintenanceWindow, error)
+	UpdateSelectedWithTx(tx *gorm.DB, where *MaintenanceWindow, mw *MaintenanceWindow, columns ...string) error
+	DeleteWithTx(tx *gorm.DB, where *MaintenanceWindow) error
+	ListByUserID(ctx context.Context, userID uint, limit, offset int) ([]MaintenanceWindow, int64, error)
+	ReplaceWebsitesWithTx(tx *gorm.DB, windowID uint, websites []MaintenanceWindowWebsite) error
+	ListForWebsite(tx *gorm.DB, userID, websiteID uint) ([]MaintenanceWindow, error)
+	CoversWebsite(tx *gorm.DB, userID, websiteID uint, t time.Time) (bool, error)
+}
+
+type IStatusPage interface {
+	CreateWithTx(tx *gorm.DB, sp *StatusPage) error
+	GetWithTx(tx *gorm.DB, where *StatusPage) (*StatusPage, error)
+	UpdateSelectedWithTx(tx *gorm.DB, where *StatusPage, sp *StatusPage, columns ...string) error
+	ReplaceWebsitesWithTx(tx *gorm.DB, statusPageID uint, websites []StatusPageWebsite) error
+	DeleteWithTx(tx *gorm.DB, where *StatusPage) error
+	ListByUserID(ctx context.Context, userID uint, limit, offset int) ([]StatusPage, int64, error)
+	ListEntriesByWebsiteID(tx *gorm.DB, websiteID uint) ([]StatusPageWebsite, error)
+}
+
+type IStatusPageSubscriber interface {
+	CreateWithTx(tx *gorm.DB, s *StatusPageSubscriber) error
+	GetWithTx(tx *gorm.DB, where *StatusPageSubscriber) (*StatusPageSubscriber, error)
+	UpdateSelectedWithTx(tx *gorm.DB, where *StatusPageSubscriber, s *StatusPageSubscriber, columns ...string) error
+	DeleteWithTx(tx *gorm.DB, where *StatusPageSubscriber) error
+	ListByStatusPageID(ctx context.Context, statusPageID uint, limit, offset int) ([]StatusPageSubscriber, int64, error)
+	ListConfirmedAfter(tx *gorm.DB, statusPageID, afterID uint, limit int) ([]StatusPageSubscriber, error)
+}
+
+type ISubscriberNotification interface {
+	CreateWithTx(tx *gorm.DB, sn *SubscriberNotification) error
+	GetWithTx(tx *gorm.DB, where *SubscriberNotification) (*SubscriberNotification, error)
+	UpdateSelectedWithTx(tx *gorm.DB, where *SubscriberNotification, sn *SubscriberNotification, columns ...string) error
+}
+
+type IHeartbeatPing interface {
+	CreateWithTx(tx *gorm.DB, hp *HeartbeatPing) error
+	GetLatestWithTx(tx *gorm.DB, websiteID uint, signal HeartbeatSignal) (*HeartbeatPing, error)
+	ListByWebsiteID(ctx context.Context, websiteID uint, limit, offset int) ([]HeartbeatPing, int64, error)
 }
@@ -13,6 +13,8 @@ type HealthStatus string
 const (
 	Healthy   HealthStatus = "HEALTHY"
 	Unhealthy HealthStatus = "UNHEALTHY"
+	//only used for notifications, a certificate about to expire does not make a check unhealthy
+	CertificateExpiring HealthStatus = "CERT_EXPIRING"
 )
 
 type Log struct {
@@ -23,6 +25,54 @@ type Log struct {
 	StatusCode   uint   `gorm:"not null" json:"status_code"`
 	LatencyInMS  uint   `gorm:"not null" json:"latency_in_ms"`
 	HealthStatus string `gorm:"not null" json:"health_status"`
+	//probe location the check ran from, see ProbeLocation
+	Location string `gorm:"not null;default:default;index" json:"location"`
+	//checks during a maintenance window are kept but do not count towards incidents or stats
+	InMaintenance bool `gorm:"not null;default:false" json:"in_maintenance"`
+
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// LogStats summarises the checks of a website over a time range, checks during maintenance are left out
+type LogStats struct {
+	TotalChecks      int64   `json:"total_checks"`
+	HealthyChecks    int64   `json:"healthy_checks"`
+	UptimePercentage float64 `json:"uptime_percentage"`
+	P50LatencyInMS   float64 `json:"p50_latency_in_ms"`
+	P95LatencyInMS   float64 `json:"p95_latency_in_ms"`
+	P99LatencyInMS   float64 `json:"p99_latency_in_ms"`
+}
+
+// LogStatsBucket is LogStats for a single minute/hour/day bucket
+type LogStatsBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	LogStats
+}
+
+// LocationLogStats is LogStats of the checks run from a single probe location
+type LocationLogStats struct {
+	Location string `json:"location"`
+	LogStats
+}
+
+// LocationHealthStatus is the health status of a single check along with where it ran from
+type LocationHealthStatus struct {
+	Location     string
+	HealthStatus string
+}
+
+// DailyUptime is the share of healthy checks of a website on a single day (UTC)
+type DailyUptime struct {
+	WebsiteId        uint      `json:"-"`
+	Day              time.Time `json:"day"`
+	TotalChecks      int64     `json:"total_checks"`
+	HealthyChecks    int64     `json:"healthy_checks"`
+	UptimePercentage float64   `json:"uptime_percentage"`
+}
+
+type StatusCodeCount struct {
+	StatusCode uint  `json:"status_code"`
+	Count      int64 `json:"count"`
 }
 
 type logsRepo struct {
@@ -38,13 +88,71 @@ func (lr *logsRepo) Create(ctx context.Context, log Log) error {
 	return nil
 }
 
+func (lr *logsRepo) CreateWithTx(tx *gorm.DB, log Log) error {
+	err := tx.Create(&log).Error
+	if err != nil {
+		logger.Error("error in creating log entry | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// CreateWithSteps creates the log of a flow check along with the logs of its steps
+func (lr *logsRepo) CreateWithSteps(ctx context.Context, log Log, steps []StepLog) error {
+	return lr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&log).Error
+		if err != nil {
+			logger.Error("error in creating log entry | err: ", err)
+			return err
+		}
+		if len(steps) == 0 {
+			return nil
+		}
+
+		for i := range steps {
+			steps[i].LogId = log.ID
+			steps[i].WebsiteId = log.WebsiteId
+		}
+		err = tx.Create(&steps).Error
+		if err != nil {
+			logger.Error("error in creating step logs | err: ", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// ListStepLogsByWebsiteID returns the step logs of the website, latest check first and in step order within
+// a check, along with the total count
+func (lr *logsRepo) ListStepLogsByWebsiteID(ctx context.Context, websiteID uint, limit, offset int) ([]StepLog, int64, error) {
+	var (
+		steps []StepLog
+		total int64
+	)
+
+	query := lr.db.WithContext(ctx).Model(&StepLog{}).Where("website_id = ?", websiteID).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting step logs | err: ", err)
+		return nil, 0, err
+	}
+
+	err = query.Order("log_id DESC, position").Limit(limit).Offset(offset).Find(&steps).Error
+	if err != nil {
+		logger.Error("error in listing step logs | err: ", err)
+		return nil, 0, err
+	}
+	return steps, total, nil
+}
+
 func (lr *logsRepo) FetchPastRecordStatusByWebsiteID(ctx context.Context, limit uint, webisteID uint) ([]string, error) {
 	var (
 		statusLogs []string
 	)
 	err := lr.db.WithContext(ctx).Raw(`
 	SELECT health_status FROM logs
-	WHERE website_id = $1 
+	WHERE website_id = $1 AND in_maintenance = false
 	ORDER BY created_at DESC
 	LIMIT $2
 	`, webisteID, limit).Scan(&statusLogs).Error
@@ -54,3 +162,122 @@ func (lr *logsRepo) FetchPastRecordStatusByWebsiteID(ctx context.Context, limit
 	}
 	return statusLogs, err
 }
+
+// statsColumns aggregates the selected logs into the LogStats columns
+const statsColumns = `
+	COUNT(*) AS total_checks,
+	COUNT(*) FILTER (WHERE health_status = 'HEALTHY') AS healthy_checks,
+	COALESCE(100.0 * COUNT(*) FILTER (WHERE health_status = 'HEALTHY') / NULLIF(COUNT(*), 0), 0) AS uptime_percentage,
+	COALESCE(percentile_cont(0.50) WITHIN GROUP (ORDER BY latency_in_ms), 0) AS p50_latency_in_ms,
+	COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_in_ms), 0) AS p95_latency_in_ms,
+	COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY latency_in_ms), 0) AS p99_latency_in_ms`
+
+func (lr *logsRepo) FetchStatsByWebsiteID(ctx context.Context, websiteID uint, from, to time.Time) (*LogStats, error) {
+	var stats LogStats
+	err := lr.db.WithContext(ctx).Raw(`
+	SELECT `+statsColumns+`
+	FROM logs
+	WHERE website_id = $1 AND created_at >= $2 AND created_at < $3 AND in_maintenance = false
+	`, websiteID, from, to).Scan(&stats).Error
+	if err != nil {
+		logger.Error("error in fetching log stats | err: ", err)
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// FetchStatsSeriesByWebsiteID buckets the stats by bucket which should be a valid date_trunc field (minute/hour/day)
+func (lr *logsRepo) FetchStatsSeriesByWebsiteID(ctx context.Context, websiteID uint, from, to time.Time, bucket string) ([]LogStatsBucket, error) {
+	var series []LogStatsBucket
+	err := lr.db.WithContext(ctx).Raw(`
+	SELECT date_trunc($4, created_at) AS bucket_start, `+statsColumns+`
+	FROM logs
+	WHERE website_id = $1 AND created_at >= $2 AND created_at < $3 AND in_maintenance = false
+	GROUP BY bucket_start
+	ORDER BY bucket_start
+	`, websiteID, from, to, bucket).Scan(&series).Error
+	if err != nil {
+		logger.Error("error in fetching log stats series | err: ", err)
+		return nil, err
+	}
+	return series, nil
+}
+
+// FetchFailedStatusCodesByWebsiteID counts the unhealthy checks per status code, 0 being connection level failures
+func (lr *logsRepo) FetchFailedStatusCodesByWebsiteID(ctx context.Context, websiteID uint, from, to time.Time) ([]StatusCodeCount, error) {
+	var counts []StatusCodeCount
+	err := lr.db.WithContext(ctx).Raw(`
+	SELECT status_code, COUNT(*) AS count
+	FROM logs
+	WHERE website_id = $1 AND created_at >= $2 AND created_at < $3 AND in_maintenance = false AND health_status = 'UNHEALTHY'
+	GROUP BY status_code
+	ORDER BY count DESC
+	`, websiteID, from, to).Scan(&counts).Error
+	if err != nil {
+		logger.Error("error in fetching status code breakdown | err: ", err)
+		return nil, err
+	}
+	return counts, nil
+}
+
+// FetchRecentStatusesByLocation returns upto limit of the latest statuses of every location which checked the
+// website since the given time, latest first within a location
+func (lr *logsRepo) FetchRecentStatusesByLocation(ctx context.Context, websiteID uint, since time.Time, limit int) ([]LocationHealthStatus, error) {
+	var statuses []LocationHealthStatus
+	err := lr.db.WithContext(ctx).Raw(`
+	SELECT location, health_status FROM (
+		SELECT location, health_status, created_at,
+			row_number() OVER (PARTITION BY location ORDER BY created_at DESC) AS position
+		FROM logs
+		WHERE website_id = $1 AND created_at >= $2 AND in_maintenance = false
+	) recent
+	WHERE position <= $3
+	ORDER BY location, created_at DESC
+	`, websiteID, since, limit).Scan(&statuses).Error
+	if err != nil {
+		logger.Error("error in fetching recent statuses by location | err: ", err)
+		return nil, err
+	}
+	return statuses, nil
+}
+
+func (lr *logsRepo) FetchStatsByLocation(ctx context.Context, websiteID uint, from, to time.Time) ([]LocationLogStats, error) {
+	var stats []LocationLogStats
+	err := lr.db.WithContext(ctx).Raw(`
+	SELECT location, `+statsColumns+`
+	FROM logs
+	WHERE website_id = $1 AND created_at >= $2 AND created_at < $3 AND in_maintenance = false
+	GROUP BY location
+	ORDER BY location
+	`, websiteID, from, to).Scan(&stats).Error
+	if err != nil {
+		logger.Error("error in fetching log stats by location | err: ", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// FetchDailyUptimeByWebsiteIDs returns the uptime per website and day since the given time, days without
+// checks are left out
+func (lr *logsRepo) FetchDailyUptimeByWebsiteIDs(ctx context.Context, websiteIDs []uint, from time.Time) ([]DailyUptime, error) {
+	var uptime []DailyUptime
+	if len(websiteIDs) == 0 {
+		return uptime, nil
+	}
+
+	err := lr.db.WithContext(ctx).Raw(`
+	SELECT website_id, date_trunc('day', created_at AT TIME ZONE 'UTC') AS day,
+		COUNT(*) AS total_checks,
+		COUNT(*) FILTER (WHERE health_status = 'HEALTHY') AS healthy_checks,
+		COALESCE(100.0 * COUNT(*) FILTER (WHERE health_status = 'HEALTHY') / NULLIF(COUNT(*), 0), 0) AS uptime_percentage
+	FROM logs
+	WHERE website_id IN ? AND created_at >= ? AND in_maintenance = false
+	GROUP BY website_id, day
+	ORDER BY website_id, day
+	`, websiteIDs, from).Scan(&uptime).Error
+	if err != nil {
+		logger.Error("error in fetching daily uptime | err: ", err)
+		return nil, err
+	}
+	return uptime, nil
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/pkg/schedule"
+	"github.com/ankur12345678/uptime-monitor/utils"
+	"gorm.io/gorm"
+)
+
+// MaintenanceWindow is a period in which websites are expected to be down, checks keep running but
+// do not open incidents or notify anyone. A window without Websites covers every website of the user.
+type MaintenanceWindow struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UUID   string `gorm:"unique;not null" json:"uuid"`
+	UserId uint   `gorm:"not null;index" json:"-"`
+	Name   string `gorm:"not null" json:"name"`
+
+	//start of the first (or only) occurrence, recurring windows repeat at its time of day in Timezone
+	StartsAt        time.Time `gorm:"not null" json:"starts_at"`
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`
+	Timezone        string    `gorm:"not null;default:UTC" json:"timezone"`
+	//empty for one-off windows, otherwise a cron expression or an RRULE (see pkg/schedule)
+	Recurrence string `json:"recurrence,omitempty"`
+	//occurrences starting after it are skipped, nil repeats forever
+	RepeatUntil *time.Time `json:"repeat_until"`
+
+	Websites []MaintenanceWindowWebsite `gorm:"foreignKey:MaintenanceWindowId;References:ID" json:"websites"`
+}
+
+// MaintenanceWindowWebsite is a website covered by a maintenance window
+type MaintenanceWindowWebsite struct {
+	ID                  uint `gorm:"primaryKey" json:"-"`
+	MaintenanceWindowId uint `gorm:"not null;uniqueIndex:idx_maintenance_window_website" json:"-"`
+	WebsiteId           uint `gorm:"not null;uniqueIndex:idx_maintenance_window_website;index" json:"-"`
+
+	Website *Website `gorm:"foreignKey:WebsiteId;References:ID" json:"website,omitempty"`
+}
+
+// preloadWindowWebsites loads the websites of the window, websites deleted since are left out
+func preloadWindowWebsites(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Websites", func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN websites ON websites.id = maintenance_window_websites.website_id AND websites.deleted_at IS NULL").
+			Order("maintenance_window_websites.id")
+	}).Preload("Websites.Website")
+}
+
+func (mw *MaintenanceWindow) BeforeCreate(tx *gorm.DB) error {
+	mw.UUID = utils.UUIDGen(constants.MAINTENANCE_WINDOW_TYPE)
+	return nil
+}
+
+// ActiveAt tells whether an occurrence of the window covers t
+func (mw *MaintenanceWindow) ActiveAt(t time.Time) (bool, error) {
+	loc, err := time.LoadLocation(mw.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		startsAt = mw.StartsAt.In(loc)
+		duration = time.Duration(mw.DurationMinutes) * time.Minute
+	)
+	if t.Before(startsAt) {
+		return false, nil
+	}
+	if mw.Recurrence == "" {
+		return t.Before(startsAt.Add(duration)), nil
+	}
+
+	recurrence, err := schedule.Parse(mw.Recurrence)
+	if err != nil {
+		return false, err
+	}
+	start, ok := recurrence.Occurrence(startsAt, duration, t)
+	if !ok {
+		return false, nil
+	}
+	return mw.RepeatUntil == nil || !start.After(*mw.RepeatUntil), nil
+}
+
+type maintenanceWindowsRepo struct {
+	db *gorm.DB
+}
+
+// CreateWithTx creates the window along with its websites
+func (mwr *maintenanceWindowsRepo) CreateWithTx(tx *gorm.DB, mw *MaintenanceWindow) error {
+	err := tx.Create(mw).Error
+	if err != nil {
+		logger.Error("error in creating maintenance window | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (mwr *maintenanceWindowsRepo) GetWithTx(tx *gorm.DB, where *MaintenanceWindow) (*MaintenanceWindow, error) {
+	var mw MaintenanceWindow
+	err := preloadWindowWebsites(tx.Model(&MaintenanceWindow{})).Where(where).First(&mw).Error
+	return &mw, err
+}
+
+func (mwr *maintenanceWindowsRepo) UpdateSelectedWithTx(tx *gorm.DB, where *MaintenanceWindow, mw *MaintenanceWindow, columns ...string) error {
+	err := tx.Model(&MaintenanceWindow{}).Where(where).Select(columns).Updates(mw).Error
+	if err != nil {
+		logger.Error("error in updating maintenance window | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// ReplaceWebsitesWithTx swaps the websites of the window for the given ones, none covers every website of the user
+func (mwr *maintenanceWindowsRepo) ReplaceWebsitesWithTx(tx *gorm.DB, windowID uint, websites []MaintenanceWindowWebsite) error {
+	err := tx.Where("maintenance_window_id = ?", windowID).Delete(&MaintenanceWindowWebsite{}).Error
+	if err != nil {
+		logger.Error("error in deleting maintenance window websites | err: ", err)
+		return err
+	}
+
+	for i := range websites {
+		websites[i].MaintenanceWindowId = windowID
+	}
+	if len(websites) == 0 {
+		return nil
+	}
+
+	err = tx.Create(&websites).Error
+	if err != nil {
+		logger.Error("error in creating maintenance window websites | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteWithTx deletes the windows along with their websites
+func (mwr *maintenanceWindowsRepo) DeleteWithTx(tx *gorm.DB, where *MaintenanceWindow) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Model(&MaintenanceWindow{}).Where(where).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Where("maintenance_window_id IN ?", ids).Delete(&MaintenanceWindowWebsite{}).Error
+		if err != nil {
+			logger.Error("error in deleting maintenance window websites | err: ", err)
+			return err
+		}
+
+		err = tx.Where("id IN ?", ids).Delete(&MaintenanceWindow{}).Error
+		if err != nil {
+			logger.Error("error in deleting maintenance window | err: ", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// ListByUserID returns the windows of the user, latest first, along with the total count
+func (mwr *maintenanceWindowsRepo) ListByUserID(ctx context.Context, userID uint, limit, offset int) ([]MaintenanceWindow, int64, error) {
+	var (
+		windows []MaintenanceWindow
+		total   int64
+	)
+
+	query := mwr.db.WithContext(ctx).Model(&MaintenanceWindow{}).Where("user_id = ?", userID).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting maintenance windows | err: ", err)
+		return nil, 0, err
+	}
+
+	err = preloadWindowWebsites(query).Order("starts_at DESC").Limit(limit).Offset(offset).Find(&windows).Error
+	if err != nil {
+		logger.Error("error in listing maintenance windows | err: ", err)
+		return nil, 0, err
+	}
+	return windows, total, nil
+}
+
+// ListForWebsite returns the windows covering the website, the ones it is attached to and the ones of all the
+// user's websites
+func (mwr *maintenanceWindowsRepo) ListForWebsite(tx *gorm.DB, userID, websiteID uint) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	err := tx.Model(&MaintenanceWindow{}).
+		Where("user_id = ?", userID).
+		Where(`NOT EXISTS (SELECT 1 FROM maintenance_window_websites mww WHERE mww.maintenance_window_id = maintenance_windows.id)
+			OR EXISTS (SELECT 1 FROM maintenance_window_websites mww WHERE mww.maintenance_window_id = maintenance_windows.id AND mww.website_id = ?)`, websiteID).
+		Find(&windows).Error
+	if err != nil {
+		logger.Error("error in listing maintenance windows of website | err: ", err)
+		return nil, err
+	}
+	return windows, nil
+}
+
+// CoversWebsite tells whether a window attached to the website (or to all the user's websites) covers t, windows which
+// can not be evaluated are skipped
+func (mwr *maintenanceWindowsRepo) CoversWebsite(tx *gorm.DB, userID, websiteID uint, t time.Time) (bool, error) {
+	windows, err := mwr.ListForWebsite(tx, userID, websiteID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, window := range windows {
+		active, err := window.ActiveAt(t)
+		if err != nil {
+			logger.Error("error in evaluating maintenance window | err: ", err)
+			continue
+		}
+		if active {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// queues an outbox message is published to, incident events go to the on-call alerts queue
+const (
+	OutboxTopicIncidentEvents          = "incident-events"
+	OutboxTopicSubscriberNotifications = "subscriber-notifications"
+)
+
+// OutboxMessage is a queue message written in the same transaction as the state change which
+// caused it (eg. an incident opening), the outbox relay publishes it to the queue afterwards.
+// SentAt is nil until it is published. A message which fails to publish is tried again at
+// NextAttemptAt and given up on (DeadAt) after the relay's max attempts.
+type OutboxMessage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Payload       string     `gorm:"not null" json:"payload"`
+	Topic         string     `gorm:"not null;default:incident-events" json:"topic"`
+	SentAt        *time.Time `gorm:"index:idx_outbox_message_sent_at" json:"sent_at"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	DeadAt        *time.Time `gorm:"index:idx_outbox_message_dead_at" json:"dead_at,omitempty"`
+}
+
+type outboxMessagesRepo struct {
+	db *gorm.DB
+}
+
+func (omr *outboxMessagesRepo) CreateWithTx(tx *gorm.DB, m *OutboxMessage) error {
+	err := tx.Create(m).Error
+	if err != nil {
+		logger.Error("error in creating outbox message | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// ClaimUnsent locks upto limit unpublished messages which are due in the order they were written,
+// concurrent relays skip each other's rows. Dead messages are never claimed again.
+func (omr *outboxMessagesRepo) ClaimUnsent(tx *gorm.DB, limit int, now time.Time) ([]OutboxMessage, error) {
+	var messages []OutboxMessage
+	err := tx.Model(&OutboxMessage{}).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("sent_at IS NULL AND dead_at IS NULL").
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		logger.Error("error in fetching unsent outbox messages | err: ", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (omr *outboxMessagesRepo) UpdateSelectedWithTx(tx *gorm.DB, where *OutboxMessage, m *OutboxMessage, columns ...string) error {
+	err := tx.Model(&OutboxMessage{}).
+		Where(where).Select(columns).Updates(m).Error
+	if err != nil {
+		logger.Error("error in updating OutboxMessage | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteSentBefore cleans up the messages which were published before the given time
+func (omr *outboxMessagesRepo) DeleteSentBefore(tx *gorm.DB, before time.Time) (int64, error) {
+	result := tx.Where("sent_at < ?", before).Delete(&OutboxMessage{})
+	if result.Error != nil {
+		logger.Error("error in deleting sent outbox messages | err: ", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
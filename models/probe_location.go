@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProbeLocation is a named place (region, datacenter ...) the monitor-websites job runs from,
+// every running job registers its location and keeps refreshing LastSeenAt
+type ProbeLocation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Name       string    `gorm:"unique;not null" json:"name"`
+	LastSeenAt time.Time `gorm:"not null" json:"last_seen_at"`
+}
+
+// WebsiteLocationCheck is the check schedule of a website at a single location, every location
+// checks every website on its own schedule
+type WebsiteLocationCheck struct {
+	WebsiteID     uint      `gorm:"primaryKey;autoIncrement:false" json:"website_id"`
+	Location      string    `gorm:"primaryKey" json:"location"`
+	LastCheckedAt time.Time `gorm:"not null" json:"last_checked_at"`
+	NextCheckAt   time.Time `gorm:"not null" json:"next_check_at"`
+}
+
+type probeLocationsRepo struct {
+	db *gorm.DB
+}
+
+// Register creates the location or marks it as seen now
+func (plr *probeLocationsRepo) Register(tx *gorm.DB, name string) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(&ProbeLocation{Name: name, LastSeenAt: time.Now()}).Error
+	if err != nil {
+		logger.Error("error in registering probe location | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (plr *probeLocationsRepo) CountActiveSince(tx *gorm.DB, since time.Time) (int64, error) {
+	var count int64
+	err := tx.Model(&ProbeLocation{}).Where("last_seen_at >= ?", since).Count(&count).Error
+	if err != nil {
+		logger.Error("error in counting active probe locations | err: ", err)
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// QueueMessage backs the postgres queue driver (see pkg/queue), a message is invisible
+// to other consumers until VisibleAt once it is claimed
+type QueueMessage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	QueueName    string    `gorm:"not null;index:idx_queue_message_visible_at" json:"queue_name"`
+	Body         string    `gorm:"not null" json:"body"`
+	VisibleAt    time.Time `gorm:"not null;index:idx_queue_message_visible_at" json:"visible_at"`
+	ReceiveCount int       `gorm:"not null;default:0" json:"receive_count"`
+}
+
+type queueMessagesRepo struct {
+	db *gorm.DB
+}
+
+func (qr *queueMessagesRepo) CreateWithTx(tx *gorm.DB, m *QueueMessage) error {
+	err := tx.Create(m).Error
+	if err != nil {
+		logger.Error("error in creating queue message | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// ClaimBatch hides upto limit of the oldest visible messages of the queue for visibilityTimeout and returns them,
+// concurrent consumers skip each other's locked rows instead of waiting on them
+func (qr *queueMessagesRepo) ClaimBatch(tx *gorm.DB, queueName string, visibilityTimeout time.Duration, limit int) ([]QueueMessage, error) {
+	var messages []QueueMessage
+	err := tx.Raw(`UPDATE queue_messages SET visible_at = now() + make_interval(secs => ?), receive_count = receive_count + 1
+		WHERE id IN (
+			SELECT id FROM queue_messages
+			WHERE queue_name = ? AND visible_at <= now()
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT ?
+		)
+		RETURNING *`, visibilityTimeout.Seconds(), queueName, limit).Scan(&messages).Error
+	if err != nil {
+		logger.Error("error in claiming queue messages | err: ", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ExtendVisibilityWithTx keeps a claimed message hidden for another visibilityTimeout from now
+func (qr *queueMessagesRepo) ExtendVisibilityWithTx(tx *gorm.DB, id uint, visibilityTimeout time.Duration) error {
+	err := tx.Model(&QueueMessage{}).Where("id = ?", id).
+		Update("visible_at", gorm.Expr("now() + make_interval(secs => ?)", visibilityTimeout.Seconds())).Error
+	if err != nil {
+		logger.Error("error in extending queue message visibility | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (qr *queueMessagesRepo) DeleteWithTx(tx *gorm.DB, where *QueueMessage) error {
+	err := tx.Where(where).Delete(&QueueMessage{}).Error
+	if err != nil {
+		logger.Error("error in deleting queue message | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (qr *queueMessagesRepo) DeleteByIDsWithTx(tx *gorm.DB, ids []uint) error {
+	err := tx.Where("id IN ?", ids).Delete(&QueueMessage{}).Error
+	if err != nil {
+		logger.Error("error in deleting queue messages | err: ", err)
+		return err
+	}
+	return nil
+}
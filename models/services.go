@@ -45,3 +45,51 @@ func InitIncidentEventsRepo(DB *gorm.DB) IIncidentEvent {
 		db: DB,
 	}
 }
+
+func InitQueueMessagesRepo(DB *gorm.DB) IQueueMessage {
+	return &queueMessagesRepo{
+		db: DB,
+	}
+}
+
+func InitOutboxMessagesRepo(DB *gorm.DB) IOutboxMessage {
+	return &outboxMessagesRepo{
+		db: DB,
+	}
+}
+
+func InitProbeLocationsRepo(DB *gorm.DB) IProbeLocation {
+	return &probeLocationsRepo{
+		db: DB,
+	}
+}
+
+func InitMaintenanceWindowsRepo(DB *gorm.DB) IMaintenanceWindow {
+	return &maintenanceWindowsRepo{
+		db: DB,
+	}
+}
+
+func InitStatusPagesRepo(DB *gorm.DB) IStatusPage {
+	return &statusPagesRepo{
+		db: DB,
+	}
+}
+
+func InitStatusPageSubscribersRepo(DB *gorm.DB) IStatusPageSubscriber {
+	return &statusPageSubscribersRepo{
+		db: DB,
+	}
+}
+
+func InitSubscriberNotificationsRepo(DB *gorm.DB) ISubscriberNotification {
+	return &subscriberNotificationsRepo{
+		db: DB,
+	}
+}
+
+func InitHeartbeatPingsRepo(DB *gorm.DB) IHeartbeatPing {
+	return &heartbeatPingsRepo{
+		db: DB,
+	}
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/utils"
+	"gorm.io/gorm"
+)
+
+// StatusPage is a public, unauthenticated page showing the status of the selected websites of a user.
+// It is served at /status/:slug and, when CustomDomain points at the server, at the root of that domain.
+type StatusPage struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UUID         string  `gorm:"unique;not null" json:"uuid"`
+	UserId       uint    `gorm:"not null;index" json:"-"`
+	Slug         string  `gorm:"unique;not null" json:"slug"`
+	CustomDomain *string `gorm:"unique" json:"custom_domain"`
+
+	//branding
+	Title       string `gorm:"not null" json:"title"`
+	Description string `json:"description,omitempty"`
+	LogoURL     string `json:"logo_url,omitempty"`
+	AccentColor string `json:"accent_color,omitempty"`
+
+	Websites []StatusPageWebsite `gorm:"foreignKey:StatusPageId;References:ID" json:"websites"`
+}
+
+// StatusPageWebsite is a website shown on a status page, under DisplayName rather than its url
+type StatusPageWebsite struct {
+	ID           uint   `gorm:"primaryKey" json:"-"`
+	StatusPageId uint   `gorm:"not null;uniqueIndex:idx_status_page_website" json:"-"`
+	WebsiteId    uint   `gorm:"not null;uniqueIndex:idx_status_page_website;index" json:"-"`
+	DisplayName  string `gorm:"not null" json:"display_name"`
+	Position     int    `gorm:"not null;default:0" json:"position"`
+
+	Website *Website `gorm:"foreignKey:WebsiteId;References:ID" json:"website,omitempty"`
+}
+
+func (sp *StatusPage) BeforeCreate(tx *gorm.DB) error {
+	sp.UUID = utils.UUIDGen(constants.STATUS_PAGE_TYPE)
+	return nil
+}
+
+// PublicURL is where the page is served, its custom domain when it has one
+func (sp *StatusPage) PublicURL(serverBaseURL string) string {
+	if sp.CustomDomain != nil {
+		return "https://" + *sp.CustomDomain
+	}
+	return fmt.Sprintf("%s/status/%s", serverBaseURL, sp.Slug)
+}
+
+// preloadWebsites loads the websites of the page in display order, websites deleted since are left out
+func preloadWebsites(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Websites", func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN websites ON websites.id = status_page_websites.website_id AND websites.deleted_at IS NULL").
+			Order("status_page_websites.position")
+	}).Preload("Websites.Website")
+}
+
+type statusPagesRepo struct {
+	db *gorm.DB
+}
+
+// CreateWithTx creates the page along with its websites
+func (spr *statusPagesRepo) CreateWithTx(tx *gorm.DB, sp *StatusPage) error {
+	err := tx.Create(sp).Error
+	if err != nil {
+		logger.Error("error in creating status page | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (spr *statusPagesRepo) GetWithTx(tx *gorm.DB, where *StatusPage) (*StatusPage, error) {
+	var sp StatusPage
+	err := preloadWebsites(tx.Model(&StatusPage{})).Where(where).First(&sp).Error
+	return &sp, err
+}
+
+func (spr *statusPagesRepo) UpdateSelectedWithTx(tx *gorm.DB, where *StatusPage, sp *StatusPage, columns ...string) error {
+	err := tx.Model(&StatusPage{}).Where(where).Select(columns).Omit("Websites").Updates(sp).Error
+	if err != nil {
+		logger.Error("error in updating status page | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// ReplaceWebsitesWithTx swaps the websites of the page for the given ones
+func (spr *statusPagesRepo) ReplaceWebsitesWithTx(tx *gorm.DB, statusPageID uint, websites []StatusPageWebsite) error {
+	err := tx.Where("status_page_id = ?", statusPageID).Delete(&StatusPageWebsite{}).Error
+	if err != nil {
+		logger.Error("error in deleting status page websites | err: ", err)
+		return err
+	}
+
+	for i := range websites {
+		websites[i].StatusPageId = statusPageID
+	}
+	if len(websites) == 0 {
+		return nil
+	}
+
+	err = tx.Create(&websites).Error
+	if err != nil {
+		logger.Error("error in creating status page websites | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (spr *statusPagesRepo) DeleteWithTx(tx *gorm.DB, where *StatusPage) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Model(&StatusPage{}).Where(where).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Where("status_page_id IN ?", ids).Delete(&StatusPageWebsite{}).Error
+		if err != nil {
+			logger.Error("error in deleting status page websites | err: ", err)
+			return err
+		}
+
+		err = tx.Where("status_page_id IN ?", ids).Delete(&StatusPageSubscriber{}).Error
+		if err != nil {
+			logger.Error("error in deleting status page subscribers | err: ", err)
+			return err
+		}
+
+		err = tx.Where("id IN ?", ids).Delete(&StatusPage{}).Error
+		if err != nil {
+			logger.Error("error in deleting status page | err: ", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// ListByUserID returns the pages of the user, latest first, along with the total count
+func (spr *statusPagesRepo) ListByUserID(ctx context.Context, userID uint, limit, offset int) ([]StatusPage, int64, error) {
+	var (
+		pages []StatusPage
+		total int64
+	)
+
+	query := spr.db.WithContext(ctx).Model(&StatusPage{}).Where("user_id = ?", userID).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting status pages | err: ", err)
+		return nil, 0, err
+	}
+
+	err = preloadWebsites(query).Order("created_at DESC").Limit(limit).Offset(offset).Find(&pages).Error
+	if err != nil {
+		logger.Error("error in listing status pages | err: ", err)
+		return nil, 0, err
+	}
+	return pages, total, nil
+}
+
+// ListEntriesByWebsiteID returns the entries of the website on every status page showing it
+func (spr *statusPagesRepo) ListEntriesByWebsiteID(tx *gorm.DB, websiteID uint) ([]StatusPageWebsite, error) {
+	var entries []StatusPageWebsite
+	err := tx.Model(&StatusPageWebsite{}).Where("website_id = ?", websiteID).Find(&entries).Error
+	if err != nil {
+		logger.Error("error in listing status page entries of website | err: ", err)
+		return nil, err
+	}
+	return entries, nil
+}
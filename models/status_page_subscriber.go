@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+	"github.com/ankur12345678/uptime-monitor/utils"
+	"gorm.io/gorm"
+)
+
+type SubscriberType string
+
+const (
+	SubscriberTypeEmail SubscriberType = "email"
+	//an https url receiving a json post per update
+	SubscriberTypeWebhook SubscriberType = "webhook"
+)
+
+// StatusPageSubscriber is an end user following the incidents of a status page. Subscribers only get
+// updates once they opened the confirmation link (double opt-in), the unsubscribe token is part of every update.
+type StatusPageSubscriber struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	StatusPageId uint           `gorm:"not null;uniqueIndex:idx_status_page_subscriber" json:"-"`
+	Type         SubscriberType `gorm:"not null;uniqueIndex:idx_status_page_subscriber" json:"type"`
+	Target       string         `gorm:"not null;uniqueIndex:idx_status_page_subscriber" json:"target"`
+
+	ConfirmationToken  string     `gorm:"index:idx_subscriber_confirmation_token" json:"-"`
+	ConfirmationSentAt *time.Time `json:"-"`
+	ConfirmedAt        *time.Time `json:"confirmed_at"`
+	UnsubscribeToken   string     `gorm:"unique;not null" json:"-"`
+}
+
+type SubscriberEvent string
+
+const (
+	SubscriberEventIncidentOpened   SubscriberEvent = "incident_opened"
+	SubscriberEventIncidentResolved SubscriberEvent = "incident_resolved"
+)
+
+// SubscriberNotification is a single update of a status page which is fanned out to all of its confirmed
+// subscribers. Subscribers are notified in id order and LastSubscriberId is moved after every batch, so a
+// redelivered fan-out carries on where it stopped instead of notifying everyone again.
+type SubscriberNotification struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UUID         string          `gorm:"unique;not null" json:"uuid"`
+	StatusPageId uint            `gorm:"not null;index" json:"status_page_id"`
+	IncidentId   uint            `gorm:"not null" json:"incident_id"`
+	Event        SubscriberEvent `gorm:"not null" json:"event"`
+	//display name of the website on the status page
+	Component string `gorm:"not null" json:"component"`
+
+	LastSubscriberId uint       `gorm:"not null;default:0" json:"last_subscriber_id"`
+	Attempts         int        `gorm:"not null;default:0" json:"attempts"`
+	LastError        string     `json:"last_error,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at"`
+}
+
+func (sn *SubscriberNotification) BeforeCreate(tx *gorm.DB) error {
+	sn.UUID = utils.UUIDGen(constants.SUBSCRIBER_NOTIFICATION_TYPE)
+	return nil
+}
+
+type statusPageSubscribersRepo struct {
+	db *gorm.DB
+}
+
+func (sr *statusPageSubscribersRepo) CreateWithTx(tx *gorm.DB, s *StatusPageSubscriber) error {
+	err := tx.Create(s).Error
+	if err != nil {
+		logger.Error("error in creating status page subscriber | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (sr *statusPageSubscribersRepo) GetWithTx(tx *gorm.DB, where *StatusPageSubscriber) (*StatusPageSubscriber, error) {
+	var s StatusPageSubscriber
+	err := tx.Model(&StatusPageSubscriber{}).Where(where).First(&s).Error
+	return &s, err
+}
+
+func (sr *statusPageSubscribersRepo) UpdateSelectedWithTx(tx *gorm.DB, where *StatusPageSubscriber, s *StatusPageSubscriber, columns ...string) error {
+	err := tx.Model(&StatusPageSubscriber{}).Where(where).Select(columns).Updates(s).Error
+	if err != nil {
+		logger.Error("error in updating status page subscriber | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (sr *statusPageSubscribersRepo) DeleteWithTx(tx *gorm.DB, where *StatusPageSubscriber) error {
+	err := tx.Where(where).Delete(&StatusPageSubscriber{}).Error
+	if err != nil {
+		logger.Error("error in deleting status page subscriber | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// ListByStatusPageID returns the subscribers of the page, latest first, along with the total count
+func (sr *statusPageSubscribersRepo) ListByStatusPageID(ctx context.Context, statusPageID uint, limit, offset int) ([]StatusPageSubscriber, int64, error) {
+	var (
+		subscribers []StatusPageSubscriber
+		total       int64
+	)
+
+	query := sr.db.WithContext(ctx).Model(&StatusPageSubscriber{}).Where("status_page_id = ?", statusPageID).Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting status page subscribers | err: ", err)
+		return nil, 0, err
+	}
+
+	err = query.Order("id DESC").Limit(limit).Offset(offset).Find(&subscribers).Error
+	if err != nil {
+		logger.Error("error in listing status page subscribers | err: ", err)
+		return nil, 0, err
+	}
+	return subscribers, total, nil
+}
+
+// ListConfirmedAfter returns upto limit confirmed subscribers of the page with an id above afterID, in id order
+func (sr *statusPageSubscribersRepo) ListConfirmedAfter(tx *gorm.DB, statusPageID, afterID uint, limit int) ([]StatusPageSubscriber, error) {
+	var subscribers []StatusPageSubscriber
+	err := tx.Model(&StatusPageSubscriber{}).
+		Where("status_page_id = ? AND id > ? AND confirmed_at IS NOT NULL", statusPageID, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&subscribers).Error
+	if err != nil {
+		logger.Error("error in listing confirmed subscribers | err: ", err)
+		return nil, err
+	}
+	return subscribers, nil
+}
+
+type subscriberNotificationsRepo struct {
+	db *gorm.DB
+}
+
+func (snr *subscriberNotificationsRepo) CreateWithTx(tx *gorm.DB, sn *SubscriberNotification) error {
+	err := tx.Create(sn).Error
+	if err != nil {
+		logger.Error("error in creating subscriber notification | err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (snr *subscriberNotificationsRepo) GetWithTx(tx *gorm.DB, where *SubscriberNotification) (*SubscriberNotification, error) {
+	var sn SubscriberNotification
+	err := tx.Model(&SubscriberNotification{}).Where(where).First(&sn).Error
+	return &sn, err
+}
+
+func (snr *subscriberNotificationsRepo) UpdateSelectedWithTx(tx *gorm.DB, where *SubscriberNotification, sn *SubscriberNotification, columns ...string) error {
+	err := tx.Model(&SubscriberNotification{}).Where(where).Select(columns).Updates(sn).Error
+	if err != nil {
+		logger.Error("error in updating subscriber notification | err: ", err)
+		return err
+	}
+	return nil
+}
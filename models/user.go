@@ -20,6 +20,8 @@ type User struct {
 	Email          string `gorm:"unique" json:"email"`
 	ProfilePicture string `json:"profile_picture"`
 	Password       string `gorm:"not null" json:"omit"`
+	//admins can operate the notification pipeline (see /v1/admin routes), only set from the db
+	IsAdmin bool `gorm:"not null;default:false" json:"is_admin"`
 }
 
 type userRepo struct {
@@ -2,12 +2,46 @@ package models
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/ankur12345678/uptime-monitor/pkg/constants"
 	"github.com/ankur12345678/uptime-monitor/pkg/logger"
 	"github.com/ankur12345678/uptime-monitor/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CheckType string
+
+const (
+	CheckTypeHTTP CheckType = "http"
+	CheckTypeTCP  CheckType = "tcp"
+	CheckTypeDNS  CheckType = "dns"
+	CheckTypeTLS  CheckType = "tls"
+	//heartbeats are not checked, they are pinged by the monitored job, see HeartbeatPing
+	CheckTypeHeartbeat CheckType = "heartbeat"
+	//an ordered list of http requests, see FlowStep
+	CheckTypeFlow CheckType = "flow"
+)
+
+type DNSRecordType string
+
+const (
+	DNSRecordTypeA     DNSRecordType = "A"
+	DNSRecordTypeAAAA  DNSRecordType = "AAAA"
+	DNSRecordTypeCNAME DNSRecordType = "CNAME"
+)
+
+type AuthType string
+
+const (
+	AuthTypeNone   AuthType = "none"
+	AuthTypeBasic  AuthType = "basic"
+	AuthTypeBearer AuthType = "bearer"
 )
 
 type Website struct {
@@ -21,7 +55,83 @@ type Website struct {
 	UserId        uint      `gorm:"not null" json:"user_id"`
 	LastCheckedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_last_checked_at" json:"last_checked_at"`
 
-	User User `gorm:"foreignKey:UserId;References:ID"`
+	IntervalSeconds int       `gorm:"not null;default:180" json:"interval_seconds"`
+	NextCheckAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_next_check_at" json:"next_check_at"`
+	IsPaused        bool      `gorm:"not null;default:false" json:"is_paused"`
+
+	//CheckType decides how WebsiteURL is interpreted: a url for http, host:port for tcp/tls and a hostname for dns
+	CheckType         CheckType     `gorm:"not null;default:http" json:"check_type"`
+	DNSRecordType     DNSRecordType `json:"dns_record_type,omitempty"`
+	DNSExpectedValues []string      `gorm:"serializer:json" json:"dns_expected_values,omitempty"`
+
+	//request spec used by http checks. The auth secret (password/token), the headers and the body may all carry
+	//credentials, they are stored encrypted and only the header names and the presence of a body are returned.
+	HTTPMethod           string   `gorm:"not null;default:GET" json:"http_method"`
+	EncryptedHTTPHeaders string   `json:"-"`
+	HTTPHeaderNames      []string `gorm:"serializer:json" json:"http_header_names,omitempty"`
+	EncryptedHTTPBody    string   `json:"-"`
+	HasHTTPBody          bool     `gorm:"not null;default:false" json:"has_http_body"`
+	AuthType             AuthType `gorm:"not null;default:none" json:"auth_type"`
+	AuthUsername         string   `json:"auth_username,omitempty"`
+	EncryptedAuthSecret  string   `json:"-"`
+
+	//steps of flow checks, the auth username and secret are available to them as {{auth_username}} and {{auth_secret}}
+	FlowSteps []FlowStep `gorm:"serializer:json" json:"flow_steps,omitempty"`
+
+	//leaf certificate seen by the latest check of https/tls monitors
+	Certificate CertificateInfo `gorm:"embedded;embeddedPrefix:cert_" json:"certificate"`
+
+	//heartbeats are pinged at /v1/ping/:token at least every IntervalSeconds plus HeartbeatGraceSeconds, they are
+	//down once PingDueAt passes or a run reports a failure. NextCheckAt is when the heartbeat sweep looks at them next.
+	HeartbeatToken        *string         `gorm:"unique" json:"heartbeat_token,omitempty"`
+	HeartbeatGraceSeconds int             `gorm:"not null;default:0" json:"heartbeat_grace_seconds,omitempty"`
+	PingDueAt             *time.Time      `json:"ping_due_at,omitempty"`
+	LastPingAt            *time.Time      `json:"last_ping_at,omitempty"`
+	LastPingSignal        HeartbeatSignal `json:"last_ping_signal,omitempty"`
+
+	User User `gorm:"foreignKey:UserId;References:ID" json:"-"`
+}
+
+// CertificateInfo describes the leaf certificate of a website, ChainValid tells whether it verified
+// against the system roots for the website's hostname
+type CertificateInfo struct {
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Issuer     string     `json:"issuer,omitempty"`
+	Subject    string     `json:"subject,omitempty"`
+	SANs       []string   `gorm:"serializer:json" json:"sans,omitempty"`
+	ChainValid bool       `gorm:"not null;default:false" json:"chain_valid"`
+	ChainError string     `json:"chain_error,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at"`
+	//ExpiresAt of the certificate the expiry alert was sent for, every certificate is alerted about once
+	AlertedExpiresAt *time.Time `json:"-"`
+}
+
+// PingURL is where the heartbeat is pinged, empty for the other check types
+func (w *Website) PingURL(serverBaseURL string) string {
+	if w.HeartbeatToken == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/v1/ping/%s", serverBaseURL, *w.HeartbeatToken)
+}
+
+// HeartbeatPeriod is the time the next ping may take, counted from the latest ping
+func (w *Website) HeartbeatPeriod() time.Duration {
+	return time.Duration(w.IntervalSeconds+w.HeartbeatGraceSeconds) * time.Second
+}
+
+// WebsiteWithStatus is a website along with the health status of its latest check (empty if never checked)
+type WebsiteWithStatus struct {
+	Website
+	HealthStatus string `json:"health_status"`
+}
+
+// WebsiteFilter narrows down FetchWebsitesWithStatus, zero values are ignored
+type WebsiteFilter struct {
+	UserId       uint
+	UUID         string
+	HealthStatus string
+	Limit        int
+	Offset       int
 }
 
 type websiteRepo struct {
@@ -30,9 +140,75 @@ type websiteRepo struct {
 
 func (w *Website) BeforeCreate(tx *gorm.DB) error {
 	w.UUID = utils.UUIDGen(constants.WEBISTE_TYPE)
+
+	if w.IntervalSeconds == 0 {
+		w.IntervalSeconds = constants.DEFAULT_CHECK_INTERVAL_SECONDS
+	}
+	//spreading the first check across the interval so that bulk registrations do not land in the same run
+	if w.NextCheckAt.IsZero() {
+		w.NextCheckAt = time.Now().Add(time.Duration(rand.Int63n(int64(w.IntervalSeconds))) * time.Second)
+	}
 	return nil
 }
 
+// SetHTTPRequestSpec encrypts the headers and body of http checks into the website
+func (w *Website) SetHTTPRequestSpec(encryptionKey string, headers map[string]string, body string) error {
+	w.EncryptedHTTPHeaders, w.HTTPHeaderNames, w.EncryptedHTTPBody, w.HasHTTPBody = "", nil, "", false
+
+	if len(headers) != 0 {
+		encoded, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+		w.EncryptedHTTPHeaders, err = utils.EncryptString(encryptionKey, string(encoded))
+		if err != nil {
+			return err
+		}
+		for name := range headers {
+			w.HTTPHeaderNames = append(w.HTTPHeaderNames, name)
+		}
+		sort.Strings(w.HTTPHeaderNames)
+	}
+
+	if body != "" {
+		var err error
+		w.EncryptedHTTPBody, err = utils.EncryptString(encryptionKey, body)
+		if err != nil {
+			return err
+		}
+		w.HasHTTPBody = true
+	}
+	return nil
+}
+
+// HTTPRequestSpec decrypts the headers and body set by SetHTTPRequestSpec
+func (w *Website) HTTPRequestSpec(encryptionKey string) (map[string]string, string, error) {
+	var (
+		headers map[string]string
+		body    string
+	)
+
+	if w.EncryptedHTTPHeaders != "" {
+		decrypted, err := utils.DecryptString(encryptionKey, w.EncryptedHTTPHeaders)
+		if err != nil {
+			return nil, "", err
+		}
+		err = json.Unmarshal([]byte(decrypted), &headers)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if w.EncryptedHTTPBody != "" {
+		var err error
+		body, err = utils.DecryptString(encryptionKey, w.EncryptedHTTPBody)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return headers, body, nil
+}
+
 // Create implements IWebsite.
 func (wr *websiteRepo) Create(w *Website) error {
 	return wr.CreateWithTx(wr.db, w)
@@ -68,6 +244,19 @@ func (wr *websiteRepo) UpdateWithTx(tx *gorm.DB, where *Website, w *Website) err
 	return nil
 }
 
+// UpdateSelectedWithTx implements IWebsite.
+// Only the given columns are updated, which unlike UpdateWithTx also allows setting zero values.
+func (wr *websiteRepo) UpdateSelectedWithTx(tx *gorm.DB, where *Website, w *Website, columns ...string) error {
+	err := tx.
+		Model(&Website{}).
+		Where(where).Select(columns).Updates(w).Error
+	if err != nil {
+		logger.Error("unable to update website | err: ", err)
+		return err
+	}
+	return nil
+}
+
 // Delete implements IWebsite.
 func (wr *websiteRepo) Delete(where *Website) error {
 	return wr.DeleteWithTx(wr.db, where)
@@ -85,7 +274,10 @@ func (wr *websiteRepo) DeleteWithTx(tx *gorm.DB, where *Website) error {
 	return nil
 }
 
-func (wr *websiteRepo) FetchWebsitesInBulk(ctx context.Context, limit int) ([]Website, *gorm.DB, error) {
+// FetchWebsitesInBulk locks the websites which are due at the given location. A website is due once the
+// location's own schedule says so, or when its next_check_at was moved after the location last checked it
+// (eg. the monitor was edited or resumed), websites never checked from the location follow next_check_at.
+func (wr *websiteRepo) FetchWebsitesInBulk(ctx context.Context, location string, limit int) ([]Website, *gorm.DB, error) {
 	var websites []Website
 
 	tx := wr.db.WithContext(ctx).Begin()
@@ -94,12 +286,15 @@ func (wr *websiteRepo) FetchWebsitesInBulk(ctx context.Context, limit int) ([]We
 	}
 
 	err := tx.WithContext(ctx).Raw(`
-		SELECT * FROM websites
-		WHERE last_checked_at <= $1 AND deleted_at is NULL
-		ORDER BY last_checked_at
+		SELECT websites.* FROM websites
+		LEFT JOIN website_location_checks wlc ON wlc.website_id = websites.id AND wlc.location = $3
+		WHERE (COALESCE(wlc.next_check_at, websites.next_check_at) <= $1
+			OR (websites.next_check_at <= $1 AND websites.next_check_at > wlc.last_checked_at))
+			AND websites.is_paused = false AND websites.deleted_at is NULL AND websites.check_type <> $4
+		ORDER BY COALESCE(wlc.next_check_at, websites.next_check_at)
 		LIMIT $2
-		FOR UPDATE SKIP LOCKED
-	`, time.Now().Add(-3*time.Minute), limit).Scan(&websites).Error
+		FOR UPDATE OF websites SKIP LOCKED
+	`, time.Now(), limit, location, CheckTypeHeartbeat).Scan(&websites).Error
 	if err != nil {
 		tx.Rollback()
 		return nil, nil, err
@@ -107,3 +302,86 @@ func (wr *websiteRepo) FetchWebsitesInBulk(ctx context.Context, limit int) ([]We
 
 	return websites, tx, nil
 }
+
+// FetchDueHeartbeatIDs returns upto limit heartbeats which the sweep has to look at, they are locked one by one
+// with LockWithTx while being evaluated
+func (wr *websiteRepo) FetchDueHeartbeatIDs(ctx context.Context, limit int) ([]uint, error) {
+	var ids []uint
+	err := wr.db.WithContext(ctx).Model(&Website{}).
+		Where("check_type = ? AND is_paused = false AND next_check_at <= ?", CheckTypeHeartbeat, time.Now()).
+		Order("next_check_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		logger.Error("error in fetching due heartbeats | err: ", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MarkCertificateAlerted records that the expiry alert of the certificate expiring at expiresAt was sent, it returns
+// false if it was already recorded (eg. by another location)
+func (wr *websiteRepo) MarkCertificateAlerted(tx *gorm.DB, id uint, expiresAt time.Time) (bool, error) {
+	result := tx.Model(&Website{}).
+		Where("id = ? AND (cert_alerted_expires_at IS NULL OR cert_alerted_expires_at <> ?)", id, expiresAt).
+		Update("cert_alerted_expires_at", expiresAt)
+	if result.Error != nil {
+		logger.Error("error in marking certificate as alerted | err: ", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// LockWithTx locks the website row until tx ends, it serialises the incident decisions of the
+// locations checking the same website
+func (wr *websiteRepo) LockWithTx(tx *gorm.DB, id uint) error {
+	var website Website
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", id).First(&website).Error
+	if err != nil {
+		logger.Error("error in locking website | err: ", err)
+		return err
+	}
+	return nil
+}
+
+// FetchWebsitesWithStatus implements IWebsite.
+// It returns the websites matching the filter along with the total count ignoring limit/offset.
+func (wr *websiteRepo) FetchWebsitesWithStatus(ctx context.Context, filter WebsiteFilter) ([]WebsiteWithStatus, int64, error) {
+	var (
+		websites []WebsiteWithStatus
+		total    int64
+	)
+
+	inner := wr.db.WithContext(ctx).Model(&Website{}).
+		Select(`websites.*, COALESCE((
+			SELECT health_status FROM logs
+			WHERE logs.website_id = websites.id
+			ORDER BY logs.created_at DESC
+			LIMIT 1
+		), '') AS health_status`).
+		Where(&Website{UserId: filter.UserId, UUID: filter.UUID})
+
+	query := wr.db.WithContext(ctx).Table("(?) AS w", inner)
+	if filter.HealthStatus != "" {
+		query = query.Where("w.health_status = ?", filter.HealthStatus)
+	}
+	//new session so that the same conditions can be used for both count and find
+	query = query.Session(&gorm.Session{})
+
+	err := query.Count(&total).Error
+	if err != nil {
+		logger.Error("error in counting websites | err: ", err)
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err = query.Order("w.id").Offset(filter.Offset).Find(&websites).Error
+	if err != nil {
+		logger.Error("error in fetching websites | err: ", err)
+		return nil, 0, err
+	}
+
+	return websites, total, nil
+}
@@ -0,0 +1,248 @@
+package assertions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+)
+
+// Response is the part of a check response the assertions are evaluated against.
+// Body may be truncated by the caller, BodySize is always the full size read.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	BodySize   int64
+}
+
+// Validate makes sure an assertion can be evaluated, so broken rules are rejected at the API instead of failing checks.
+func Validate(assertion models.Assertion) error {
+	switch assertion.Type {
+	case models.AssertionTypeStatusCode:
+		_, err := parseStatusCodes(assertion.Value)
+		return err
+	case models.AssertionTypeBodyContains:
+		if assertion.Value == "" {
+			return errors.New("value is required for body_contains")
+		}
+	case models.AssertionTypeBodyRegex:
+		_, err := regexp.Compile(assertion.Value)
+		return err
+	case models.AssertionTypeJSONPath:
+		_, err := parseJSONPath(assertion.Property)
+		return err
+	case models.AssertionTypeHeader:
+		if assertion.Property == "" {
+			return errors.New("property(header name) is required for header")
+		}
+	case models.AssertionTypeMaxBodySize:
+		size, err := strconv.ParseInt(assertion.Value, 10, 64)
+		if err != nil || size <= 0 {
+			return errors.New("value should be a positive number of bytes for max_body_size")
+		}
+	default:
+		return fmt.Errorf("unsupported assertion type: %s", assertion.Type)
+	}
+	return nil
+}
+
+// HasStatusCodeAssertion tells whether the default status code evaluation is overridden
+func HasStatusCodeAssertion(rules []models.Assertion) bool {
+	for _, assertion := range rules {
+		if assertion.Type == models.AssertionTypeStatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate runs every assertion in order and returns the reason of the first failing one, empty if all pass.
+func Evaluate(rules []models.Assertion, resp Response) string {
+	for _, assertion := range rules {
+		err := evaluate(assertion, resp)
+		if err != nil {
+			return fmt.Sprintf("%s assertion failed: %s", assertion.Type, err.Error())
+		}
+	}
+	return ""
+}
+
+func evaluate(assertion models.Assertion, resp Response) error {
+	switch assertion.Type {
+	case models.AssertionTypeStatusCode:
+		ranges, err := parseStatusCodes(assertion.Value)
+		if err != nil {
+			return err
+		}
+		for _, r := range ranges {
+			if resp.StatusCode >= r[0] && resp.StatusCode <= r[1] {
+				return nil
+			}
+		}
+		return fmt.Errorf("got %d, expected %s", resp.StatusCode, assertion.Value)
+
+	case models.AssertionTypeBodyContains:
+		if !bytes.Contains(resp.Body, []byte(assertion.Value)) {
+			return fmt.Errorf("body does not contain %q", assertion.Value)
+		}
+
+	case models.AssertionTypeBodyRegex:
+		re, err := regexp.Compile(assertion.Value)
+		if err != nil {
+			return err
+		}
+		if !re.Match(resp.Body) {
+			return fmt.Errorf("body does not match %q", assertion.Value)
+		}
+
+	case models.AssertionTypeJSONPath:
+		actual, err := LookupJSONPath(resp.Body, assertion.Property)
+		if err != nil {
+			return err
+		}
+		if actual != assertion.Value {
+			return fmt.Errorf("%s is %q, expected %q", assertion.Property, actual, assertion.Value)
+		}
+
+	case models.AssertionTypeHeader:
+		values, ok := resp.Header[http.CanonicalHeaderKey(assertion.Property)]
+		if !ok {
+			return fmt.Errorf("header %s is missing", assertion.Property)
+		}
+		if assertion.Value != "" && strings.Join(values, ", ") != assertion.Value {
+			return fmt.Errorf("header %s is %q, expected %q", assertion.Property, strings.Join(values, ", "), assertion.Value)
+		}
+
+	case models.AssertionTypeMaxBodySize:
+		size, err := strconv.ParseInt(assertion.Value, 10, 64)
+		if err != nil {
+			return err
+		}
+		if resp.BodySize > size {
+			return fmt.Errorf("body is %d bytes, allowed %d bytes", resp.BodySize, size)
+		}
+
+	default:
+		return fmt.Errorf("unsupported assertion type: %s", assertion.Type)
+	}
+	return nil
+}
+
+// parseStatusCodes parses "200,204,300-399" into inclusive ranges
+func parseStatusCodes(value string) ([][2]int, error) {
+	var ranges [][2]int
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		low, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code: %s", part)
+		}
+		high := low
+		if len(bounds) == 2 {
+			high, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil || high < low {
+				return nil, fmt.Errorf("invalid status code range: %s", part)
+			}
+		}
+		ranges = append(ranges, [2]int{low, high})
+	}
+
+	if len(ranges) == 0 {
+		return nil, errors.New("value is required for status_code")
+	}
+	return ranges, nil
+}
+
+// parseJSONPath supports the dotted subset of JSONPath, eg. $.data.items[0].status
+func parseJSONPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path should start with $: %s", path)
+	}
+
+	path = strings.ReplaceAll(strings.TrimPrefix(path, "$"), "[", ".[")
+
+	var segments []string
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "[") {
+			if !strings.HasSuffix(segment, "]") {
+				return nil, fmt.Errorf("invalid json path segment: %s", segment)
+			}
+			if _, err := strconv.Atoi(segment[1 : len(segment)-1]); err != nil {
+				return nil, fmt.Errorf("invalid json path index: %s", segment)
+			}
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// LookupJSONPath returns the value at path as a string, objects and arrays are returned as json.
+func LookupJSONPath(body []byte, path string) (string, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	//numbers are kept as their text, float64 would print large integers as 1e+06
+	var current interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err = decoder.Decode(&current)
+	if err == nil && decoder.More() {
+		err = errors.New("unexpected data after the top-level value")
+	}
+	if err != nil {
+		return "", fmt.Errorf("body is not valid json: %w", err)
+	}
+
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "[") {
+			index, _ := strconv.Atoi(segment[1 : len(segment)-1])
+			list, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(list) {
+				return "", fmt.Errorf("%s not found", path)
+			}
+			current = list[index]
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s not found", path)
+		}
+		current, ok = object[segment]
+		if !ok {
+			return "", fmt.Errorf("%s not found", path)
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case nil:
+		return "null", nil
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
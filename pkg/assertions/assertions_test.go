@@ -0,0 +1,53 @@
+package assertions
+
+import "testing"
+
+func TestLookupJSONPath(t *testing.T) {
+	body := []byte(`{"id": 1234567, "count": 1000000, "ratio": 0.25, "big": 12345678901234567890, "ok": true,
+		"name": "api", "empty": null, "items": [{"id": 7}, {"id": 8}], "nested": {"a": [1, 2]}}`)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "$.id", want: "1234567"},
+		{path: "$.count", want: "1000000"},
+		{path: "$.ratio", want: "0.25"},
+		{path: "$.big", want: "12345678901234567890"},
+		{path: "$.ok", want: "true"},
+		{path: "$.name", want: "api"},
+		{path: "$.empty", want: "null"},
+		{path: "$.items[1].id", want: "8"},
+		{path: "$.nested", want: `{"a":[1,2]}`},
+		{path: "$.items[2]", wantErr: true},
+		{path: "$.missing", wantErr: true},
+		{path: "id", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := LookupJSONPath(body, tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LookupJSONPath(%q) = %q, want an error", tt.path, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("LookupJSONPath(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("LookupJSONPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLookupJSONPathInvalidBody(t *testing.T) {
+	for _, body := range []string{`not json`, `{"id": 1} trailing`, ``} {
+		_, err := LookupJSONPath([]byte(body), "$.id")
+		if err == nil {
+			t.Errorf("LookupJSONPath(%q) should fail", body)
+		}
+	}
+}
@@ -2,12 +2,11 @@ package aws
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"time"
 
-	"github.com/ankur12345678/uptime-monitor/jobs"
-	"github.com/ankur12345678/uptime-monitor/pkg/logger"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -29,52 +28,65 @@ func NewClient(cfg aws.Config) *sqs.Client {
 	return sqs.NewFromConfig(cfg)
 }
 
-func SendMessage(client *sqs.Client, queueURL string, msg *jobs.SQSIncidentEventType) error {
-	// Marshal the struct to JSON
-	bodyBytes, err := json.Marshal(msg)
-	if err != nil {
-		logger.Errorf("failed to marshal message: %w", err)
-		return err
-	}
-
-	body := string(bodyBytes)
-
-	_, err = client.SendMessage(context.TODO(), &sqs.SendMessageInput{
+func SendMessage(ctx context.Context, client *sqs.Client, queueURL string, body string) error {
+	_, err := client.SendMessage(ctx, &sqs.SendMessageInput{
 		QueueUrl:    &queueURL,
 		MessageBody: &body,
 	})
-	if err != nil {
-		return err
-	}
-	fmt.Println("✅ Message sent:", body)
-	return nil
+	return err
 }
 
-func ReceiveMessage(client *sqs.Client, queueURL string) (*types.Message, error) {
-	resp, err := client.ReceiveMessage(context.TODO(), &sqs.ReceiveMessageInput{
+// ReceiveMessages long polls for upto maxMessages (sqs allows at most 10) messages,
+// returning none when the wait time passes without a message
+func ReceiveMessages(ctx context.Context, client *sqs.Client, queueURL string, maxMessages int32) ([]types.Message, error) {
+	resp, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
 		QueueUrl:            &queueURL,
-		MaxNumberOfMessages: 1,
+		MaxNumberOfMessages: maxMessages,
 		WaitTimeSeconds:     10,
 		VisibilityTimeout:   30,
 	})
 	if err != nil {
 		return nil, err
 	}
-	if len(resp.Messages) == 0 {
-		fmt.Println("No messages.")
-		return nil, nil
-	}
-	return &resp.Messages[0], nil
+	return resp.Messages, nil
 }
 
-func DeleteMessage(client *sqs.Client, queueURL string, receiptHandle *string) error {
-	_, err := client.DeleteMessage(context.TODO(), &sqs.DeleteMessageInput{
+func DeleteMessage(ctx context.Context, client *sqs.Client, queueURL string, receiptHandle *string) error {
+	_, err := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
 		QueueUrl:      &queueURL,
 		ReceiptHandle: receiptHandle,
 	})
+	return err
+}
+
+// DeleteMessageBatch deletes upto 10 messages in one call, sqs reports failures per entry
+func DeleteMessageBatch(ctx context.Context, client *sqs.Client, queueURL string, receiptHandles []string) error {
+	entries := make([]types.DeleteMessageBatchRequestEntry, 0, len(receiptHandles))
+	for i := range receiptHandles {
+		entries = append(entries, types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: &receiptHandles[i],
+		})
+	}
+
+	resp, err := client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: &queueURL,
+		Entries:  entries,
+	})
 	if err != nil {
 		return err
 	}
-	fmt.Println("🗑️ Message deleted.")
+	if len(resp.Failed) != 0 {
+		return fmt.Errorf("failed to delete %d of %d messages: %s", len(resp.Failed), len(entries), aws.ToString(resp.Failed[0].Message))
+	}
 	return nil
 }
+
+func ChangeMessageVisibility(ctx context.Context, client *sqs.Client, queueURL string, receiptHandle string, timeout time.Duration) error {
+	_, err := client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          &queueURL,
+		ReceiptHandle:     &receiptHandle,
+		VisibilityTimeout: int32(timeout.Seconds()),
+	})
+	return err
+}
@@ -9,9 +9,94 @@ const (
 	WEBISTE_TYPE        = "WEBSITE"
 	USER_TYPE           = "USER"
 	INCIDENT_EVENT_TYPE = "INCIDENT_EVENT"
+
+	MAINTENANCE_WINDOW_TYPE = "MAINTENANCE_WINDOW"
+	STATUS_PAGE_TYPE        = "STATUS_PAGE"
+
+	SUBSCRIBER_NOTIFICATION_TYPE = "SUBSCRIBER_NOTIFICATION"
 )
 
 type Error struct {
 	Field       string `json:"field"`
 	Description string `json:"description"`
 }
+
+const (
+	DEFAULT_CHECK_INTERVAL_SECONDS = 180
+	MIN_CHECK_INTERVAL_SECONDS     = 30
+	MAX_CHECK_INTERVAL_SECONDS     = 24 * 60 * 60
+)
+
+// heartbeat monitors are pinged at least once per period (their interval) plus the grace time, a started run has
+// to finish within the grace time. While a heartbeat is down it is looked at again every HEARTBEAT_RECHECK_SECONDS
+// so that incidents escalate, the body of a ping is kept upto HEARTBEAT_EXCERPT_MAX_BYTES.
+const (
+	MIN_HEARTBEAT_PERIOD_SECONDS    = 60
+	MAX_HEARTBEAT_PERIOD_SECONDS    = 31 * 24 * 60 * 60
+	DEFAULT_HEARTBEAT_GRACE_SECONDS = 5 * 60
+	MIN_HEARTBEAT_GRACE_SECONDS     = 60
+	MAX_HEARTBEAT_GRACE_SECONDS     = 24 * 60 * 60
+	HEARTBEAT_RECHECK_SECONDS       = 60
+	HEARTBEAT_EXCERPT_MAX_BYTES     = 10 * 1024
+	HEARTBEAT_SWEEP_BATCH_SIZE      = 100
+)
+
+const (
+	DEFAULT_PAGE_SIZE = 20
+	MAX_PAGE_SIZE     = 100
+)
+
+// email targets confirm within ALERT_TARGET_VERIFICATION_TTL_HOURS, adding an unconfirmed one again resends
+// the link at most once per ALERT_TARGET_VERIFICATION_RESEND_MINUTES
+const (
+	ALERT_TARGET_VERIFICATION_TTL_HOURS      = 24
+	ALERT_TARGET_VERIFICATION_RESEND_MINUTES = 10
+)
+
+const (
+	MAX_ESCALATION_LEVEL          = 2
+	INCIDENT_ACK_LINK_EXPIRY_DAYS = 7
+)
+
+// sms/voice alerts cost money and wake people up, so each phone target gets at most
+// PHONE_ALERT_RATE_LIMIT alerts per PHONE_ALERT_RATE_LIMIT_WINDOW_MINUTES
+const (
+	PHONE_ALERT_RATE_LIMIT                = 5
+	PHONE_ALERT_RATE_LIMIT_WINDOW_MINUTES = 60
+)
+
+// a probe location counts towards the incident quorum while it was seen within PROBE_LOCATION_ACTIVE_MINUTES,
+// jobs which are not given a location all probe from DEFAULT_PROBE_LOCATION
+const (
+	DEFAULT_PROBE_LOCATION        = "default"
+	PROBE_LOCATION_ACTIVE_MINUTES = 10
+)
+
+// an occurrence of a maintenance window lasts at most a week
+const MAX_MAINTENANCE_WINDOW_MINUTES = 7 * 24 * 60
+
+// public status pages show STATUS_PAGE_HISTORY_DAYS of daily uptime bars and the incidents of the last
+// STATUS_PAGE_INCIDENT_DAYS, the rendered summary is cached for STATUS_PAGE_CACHE_TTL_SECONDS
+const (
+	MAX_STATUS_PAGE_WEBSITES      = 50
+	STATUS_PAGE_HISTORY_DAYS      = 90
+	STATUS_PAGE_INCIDENT_DAYS     = 14
+	STATUS_PAGE_INCIDENT_LIMIT    = 20
+	STATUS_PAGE_CACHE_TTL_SECONDS = 60
+)
+
+// status page subscribers confirm within STATUS_PAGE_SUBSCRIBER_CONFIRMATION_TTL_HOURS, the confirmation is resent
+// at most once per STATUS_PAGE_SUBSCRIBER_RESEND_MINUTES and a client can subscribe upto
+// STATUS_PAGE_SUBSCRIBE_RATE_LIMIT times per STATUS_PAGE_SUBSCRIBE_RATE_LIMIT_WINDOW_MINUTES
+const (
+	STATUS_PAGE_SUBSCRIBER_CONFIRMATION_TTL_HOURS   = 24
+	STATUS_PAGE_SUBSCRIBER_RESEND_MINUTES           = 10
+	STATUS_PAGE_SUBSCRIBE_RATE_LIMIT                = 20
+	STATUS_PAGE_SUBSCRIBE_RATE_LIMIT_WINDOW_MINUTES = 60
+)
+
+// flow checks run at most MAX_FLOW_STEPS requests, each extracting at most MAX_FLOW_EXTRACTIONS variables
+const (
+	MAX_FLOW_STEPS       = 10
+	MAX_FLOW_EXTRACTIONS = 10
+)
@@ -0,0 +1,201 @@
+// Package flow holds what the api and the checker share about flow checks: validating the steps, substituting
+// the {{variables}} of a step and extracting them from its response.
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"github.com/ankur12345678/uptime-monitor/pkg/assertions"
+	"github.com/ankur12345678/uptime-monitor/pkg/constants"
+)
+
+// variables every step can refer to, the auth username and (decrypted) secret of the monitor
+const (
+	AuthUsernameVariable = "auth_username"
+	AuthSecretVariable   = "auth_secret"
+)
+
+var (
+	variableReference = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	variableName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// StepName is the name of the step, its position when it has none
+func StepName(step models.FlowStep, index int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	return fmt.Sprintf("step %d", index+1)
+}
+
+// Validate makes sure the steps can run, so broken flows are rejected at the API instead of failing checks.
+// Steps may only refer to the variables extracted by earlier steps.
+func Validate(steps []models.FlowStep) error {
+	if len(steps) == 0 {
+		return errors.New("at least one step is required")
+	}
+	if len(steps) > constants.MAX_FLOW_STEPS {
+		return fmt.Errorf("at most %d steps are allowed", constants.MAX_FLOW_STEPS)
+	}
+
+	defined := map[string]bool{AuthUsernameVariable: true, AuthSecretVariable: true}
+	for i, step := range steps {
+		name := StepName(step, i)
+
+		switch strings.ToUpper(step.Method) {
+		case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("%s: unsupported method %s", name, step.Method)
+		}
+		if strings.TrimSpace(step.URL) == "" {
+			return fmt.Errorf("%s: url is required", name)
+		}
+		//values are escaped for the path or query they land in, there is no escaping them into the host
+		if reference := variableReference.FindStringIndex(step.URL); reference != nil && reference[0] < hostEnd(step.URL) {
+			return fmt.Errorf("%s: variables are only allowed in the path and query of the url", name)
+		}
+
+		texts := []string{step.URL, step.Body}
+		for _, value := range step.Headers {
+			texts = append(texts, value)
+		}
+		for _, text := range texts {
+			for _, reference := range variableReference.FindAllStringSubmatch(text, -1) {
+				if !defined[reference[1]] {
+					return fmt.Errorf("%s: variable %s is not extracted by an earlier step", name, reference[1])
+				}
+			}
+		}
+
+		for _, assertion := range step.Assertions {
+			err := assertions.Validate(assertion)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		if len(step.Extract) > constants.MAX_FLOW_EXTRACTIONS {
+			return fmt.Errorf("%s: at most %d extractions are allowed", name, constants.MAX_FLOW_EXTRACTIONS)
+		}
+		for _, extraction := range step.Extract {
+			if !variableName.MatchString(extraction.Variable) {
+				return fmt.Errorf("%s: invalid variable name %q", name, extraction.Variable)
+			}
+			err := validateExtraction(extraction)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			defined[extraction.Variable] = true
+		}
+	}
+	return nil
+}
+
+func validateExtraction(extraction models.FlowExtraction) error {
+	switch extraction.Source {
+	case models.ExtractionSourceJSONPath:
+		return assertions.Validate(models.Assertion{Type: models.AssertionTypeJSONPath, Property: extraction.Property})
+	case models.ExtractionSourceRegex:
+		if extraction.Property == "" {
+			return errors.New("property(regex) is required for regex")
+		}
+		_, err := regexp.Compile(extraction.Property)
+		return err
+	case models.ExtractionSourceHeader:
+		if extraction.Property == "" {
+			return errors.New("property(header name) is required for header")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported extraction source: %s", extraction.Source)
+}
+
+// hostEnd is the index in a step url where its path, query or fragment starts, the scheme is optional
+func hostEnd(rawURL string) int {
+	start := 0
+	if scheme := strings.Index(rawURL, "://"); scheme >= 0 {
+		start = scheme + len("://")
+	}
+	end := strings.IndexAny(rawURL[start:], "/?#")
+	if end < 0 {
+		return len(rawURL)
+	}
+	return start + end
+}
+
+// Substitute replaces the {{variables}} of text with their values
+func Substitute(text string, variables map[string]string) (string, error) {
+	return substitute(text, variables, func(value string) string { return value })
+}
+
+// SubstituteURL replaces the {{variables}} of a step url with their values, path escaped before the query and
+// query escaped after it, so an extracted value can not change where the request goes
+func SubstituteURL(rawURL string, variables map[string]string) (string, error) {
+	query := strings.IndexAny(rawURL, "?#")
+	if query < 0 {
+		query = len(rawURL)
+	}
+
+	path, err := substitute(rawURL[:query], variables, url.PathEscape)
+	if err != nil {
+		return "", err
+	}
+	rest, err := substitute(rawURL[query:], variables, url.QueryEscape)
+	if err != nil {
+		return "", err
+	}
+	return path + rest, nil
+}
+
+func substitute(text string, variables map[string]string, escape func(string) string) (string, error) {
+	var missing string
+	substituted := variableReference.ReplaceAllStringFunc(text, func(reference string) string {
+		name := variableReference.FindStringSubmatch(reference)[1]
+		value, ok := variables[name]
+		if !ok {
+			if missing == "" {
+				missing = name
+			}
+			return reference
+		}
+		return escape(value)
+	})
+	if missing != "" {
+		return "", fmt.Errorf("variable %s is not defined", missing)
+	}
+	return substituted, nil
+}
+
+// Extract reads the value of the extraction from the response
+func Extract(extraction models.FlowExtraction, resp assertions.Response) (string, error) {
+	switch extraction.Source {
+	case models.ExtractionSourceJSONPath:
+		return assertions.LookupJSONPath(resp.Body, extraction.Property)
+	case models.ExtractionSourceRegex:
+		re, err := regexp.Compile(extraction.Property)
+		if err != nil {
+			return "", err
+		}
+		match := re.FindSubmatch(resp.Body)
+		if match == nil {
+			return "", fmt.Errorf("body does not match %q", extraction.Property)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	case models.ExtractionSourceHeader:
+		values, ok := resp.Header[http.CanonicalHeaderKey(extraction.Property)]
+		if !ok {
+			return "", fmt.Errorf("header %s is missing", extraction.Property)
+		}
+		return strings.Join(values, ", "), nil
+	}
+	return "", fmt.Errorf("unsupported extraction source: %s", extraction.Source)
+}
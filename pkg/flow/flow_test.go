@@ -0,0 +1,68 @@
+package flow
+
+import (
+	"testing"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+)
+
+func TestSubstituteURL(t *testing.T) {
+	variables := map[string]string{
+		"id":    "a/b c",
+		"token": "x&admin=1#frag",
+		"next":  "?q=1",
+	}
+
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{url: "https://api.test/users/{{id}}", want: "https://api.test/users/a%2Fb%20c"},
+		{url: "https://api.test/users/{{next}}", want: "https://api.test/users/%3Fq=1"},
+		{url: "https://api.test/me?token={{token}}", want: "https://api.test/me?token=x%26admin%3D1%23frag"},
+		{url: "api.test/{{id}}?id={{id}}", want: "api.test/a%2Fb%20c?id=a%2Fb+c"},
+		{url: "https://api.test/{{missing}}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := SubstituteURL(tt.url, variables)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SubstituteURL(%q) = %q, want an error", tt.url, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SubstituteURL(%q) returned error: %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SubstituteURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestValidateVariablesInURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://api.test/users/{{auth_username}}"},
+		{url: "api.test/users?name={{auth_username}}"},
+		{url: "https://api.test?name={{auth_username}}"},
+		{url: "https://{{auth_username}}.api.test/users", wantErr: true},
+		{url: "{{auth_username}}/users", wantErr: true},
+		{url: "https://api.test:{{auth_username}}/users", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := Validate([]models.FlowStep{{URL: tt.url}})
+		if tt.wantErr && err == nil {
+			t.Errorf("Validate(%q) returned no error", tt.url)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("Validate(%q) returned error: %v", tt.url, err)
+		}
+	}
+}
@@ -40,6 +40,28 @@ type Graceful struct {
 	State           *ServerState
 }
 
+// ShutdownContext returns a context which is cancelled on the first interrupt/SIGTERM.
+// It is meant for long running workers (no http server) which should drain instead of exiting immediately.
+func ShutdownContext(parent context.Context, state *ServerState) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(quit)
+		select {
+		case foundSignal := <-quit:
+			logger.Info("signal received, starting to shut down: ", foundSignal)
+			state.Shutdown()
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
 func optimiseListenAddress(addr string) string {
 	if strings.HasPrefix(addr, ":") {
 		return "localhost" + addr
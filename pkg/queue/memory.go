@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryMessage struct {
+	id        string
+	body      []byte
+	visibleAt time.Time
+}
+
+// memoryQueue keeps messages in process, with the same visibility semantics as the other drivers. It can not
+// be selected through Config.QueueDriver: the producers and the notification job run as separate processes and
+// would never see each other's messages. It is for tests and for producer and consumer sharing one process.
+type memoryQueue struct {
+	mu       sync.Mutex
+	nextID   int
+	messages []*memoryMessage
+
+	visibilityTimeout time.Duration
+	waitTime          time.Duration
+}
+
+func NewMemoryQueue() *memoryQueue {
+	return &memoryQueue{visibilityTimeout: VisibilityTimeout, waitTime: waitTime}
+}
+
+func (mq *memoryQueue) Send(ctx context.Context, body []byte) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	mq.nextID++
+	mq.messages = append(mq.messages, &memoryMessage{
+		id:        strconv.Itoa(mq.nextID),
+		body:      append([]byte(nil), body...),
+		visibleAt: time.Now(),
+	})
+	return nil
+}
+
+func (mq *memoryQueue) Receive(ctx context.Context, max int) ([]*Message, error) {
+	deadline := time.Now().Add(mq.waitTime)
+
+	for {
+		messages := mq.claim(batchSize(max))
+		if len(messages) != 0 {
+			return messages, nil
+		}
+
+		if time.Now().After(deadline) || !sleep(ctx, 100*time.Millisecond) {
+			return nil, nil
+		}
+	}
+}
+
+func (mq *memoryQueue) claim(max int) []*Message {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	var (
+		now      = time.Now()
+		messages []*Message
+	)
+	for _, message := range mq.messages {
+		if len(messages) == max {
+			break
+		}
+		if message.visibleAt.After(now) {
+			continue
+		}
+		message.visibleAt = now.Add(mq.visibilityTimeout)
+		messages = append(messages, &Message{Body: message.body, ReceiptHandle: message.id})
+	}
+	return messages
+}
+
+func (mq *memoryQueue) Delete(ctx context.Context, receiptHandle string) error {
+	return mq.DeleteBatch(ctx, []string{receiptHandle})
+}
+
+func (mq *memoryQueue) DeleteBatch(ctx context.Context, receiptHandles []string) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	deleted := make(map[string]bool, len(receiptHandles))
+	for _, receiptHandle := range receiptHandles {
+		deleted[receiptHandle] = true
+	}
+
+	remaining := mq.messages[:0]
+	for _, message := range mq.messages {
+		if !deleted[message.id] {
+			remaining = append(remaining, message)
+		}
+	}
+	mq.messages = remaining
+	return nil
+}
+
+func (mq *memoryQueue) ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	for _, message := range mq.messages {
+		if message.id == receiptHandle {
+			message.visibleAt = time.Now().Add(timeout)
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestMemoryQueue() *memoryQueue {
+	q := NewMemoryQueue()
+	q.visibilityTimeout = 500 * time.Millisecond
+	q.waitTime = 100 * time.Millisecond
+	return q
+}
+
+func TestMemoryQueueSemantics(t *testing.T) {
+	q := newTestMemoryQueue()
+	testQueueSemantics(t, q, q.visibilityTimeout)
+}
+
+func TestMemoryQueueReceiveBatch(t *testing.T) {
+	ctx := context.Background()
+	q := newTestMemoryQueue()
+
+	for i := 0; i < MaxBatchSize+5; i++ {
+		if err := q.Send(ctx, []byte("message")); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+
+	messages, err := q.Receive(ctx, MaxBatchSize*2)
+	if err != nil || len(messages) != MaxBatchSize {
+		t.Fatalf("Receive(%d) = %d messages, %v, want %d", MaxBatchSize*2, len(messages), err, MaxBatchSize)
+	}
+	messages, err = q.Receive(ctx, 0)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Receive(0) = %d messages, %v, want 1", len(messages), err)
+	}
+}
+
+func TestMemoryQueueReceiveStopsWithContext(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	messages, err := q.Receive(ctx, 1)
+	if err != nil || len(messages) != 0 {
+		t.Fatalf("Receive = %d messages, %v", len(messages), err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Receive waited %v after the context was done", elapsed)
+	}
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	models "github.com/ankur12345678/uptime-monitor/Models"
+	"gorm.io/gorm"
+)
+
+// postgresQueue stores messages in the queue_messages table and claims them with FOR UPDATE SKIP LOCKED
+type postgresQueue struct {
+	db   *gorm.DB
+	name string
+}
+
+func NewPostgresQueue(db *gorm.DB, name string) *postgresQueue {
+	return &postgresQueue{db: db, name: name}
+}
+
+func (pq *postgresQueue) Send(ctx context.Context, body []byte) error {
+	return models.InitQueueMessagesRepo(pq.db).CreateWithTx(pq.db.WithContext(ctx), &models.QueueMessage{
+		QueueName: pq.name,
+		Body:      string(body),
+		VisibleAt: time.Now(),
+	})
+}
+
+// Receive polls the table every pollInterval until messages are claimed or the wait time is over
+func (pq *postgresQueue) Receive(ctx context.Context, max int) ([]*Message, error) {
+	queueMessagesRepo := models.InitQueueMessagesRepo(pq.db)
+	deadline := time.Now().Add(waitTime)
+
+	for {
+		claimed, err := queueMessagesRepo.ClaimBatch(pq.db.WithContext(ctx), pq.name, VisibilityTimeout, batchSize(max))
+		if err != nil {
+			return nil, err
+		}
+		if len(claimed) != 0 {
+			messages := make([]*Message, 0, len(claimed))
+			for _, message := range claimed {
+				messages = append(messages, &Message{Body: []byte(message.Body), ReceiptHandle: strconv.FormatUint(uint64(message.ID), 10)})
+			}
+			return messages, nil
+		}
+
+		if time.Now().After(deadline) || !sleep(ctx, pollInterval) {
+			return nil, nil
+		}
+	}
+}
+
+func (pq *postgresQueue) Delete(ctx context.Context, receiptHandle string) error {
+	return pq.DeleteBatch(ctx, []string{receiptHandle})
+}
+
+func (pq *postgresQueue) DeleteBatch(ctx context.Context, receiptHandles []string) error {
+	ids := make([]uint, 0, len(receiptHandles))
+	for _, receiptHandle := range receiptHandles {
+		id, err := strconv.ParseUint(receiptHandle, 10, 64)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return models.InitQueueMessagesRepo(pq.db).DeleteByIDsWithTx(pq.db.WithContext(ctx), ids)
+}
+
+func (pq *postgresQueue) ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	id, err := strconv.ParseUint(receiptHandle, 10, 64)
+	if err != nil {
+		return err
+	}
+	return models.InitQueueMessagesRepo(pq.db).ExtendVisibilityWithTx(pq.db.WithContext(ctx), uint(id), timeout)
+}
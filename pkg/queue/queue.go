@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	config "github.com/ankur12345678/uptime-monitor/Config"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const (
+	DriverSQS      = "sqs"
+	DriverRedis    = "redis"
+	DriverPostgres = "postgres"
+
+	// names of the streams/queues used by the non-sqs drivers, sqs uses Config.AwsQueueUrl and
+	// Config.AwsSubscriberQueueUrl
+	incidentEventsQueue          = "incident-events"
+	subscriberNotificationsQueue = "subscriber-notifications"
+
+	// same semantics as the sqs receive call: a received message stays hidden from other
+	// consumers for VisibilityTimeout and Receive waits upto waitTime for a message
+	VisibilityTimeout = 30 * time.Second
+	waitTime          = 10 * time.Second
+	pollInterval      = time.Second
+
+	// MaxBatchSize is the most messages sqs hands out or deletes in one call, the other drivers follow it
+	MaxBatchSize = 10
+)
+
+// Message is a received message, ReceiptHandle is what Delete expects once it is processed
+type Message struct {
+	Body          []byte
+	ReceiptHandle string
+}
+
+// Queue is an at-least-once queue: messages which are not deleted within the
+// visibility timeout are delivered again
+type Queue interface {
+	Send(ctx context.Context, body []byte) error
+	// Receive returns upto max (capped at MaxBatchSize) messages, none (without an error)
+	// if no message arrived within the wait time
+	Receive(ctx context.Context, max int) ([]*Message, error)
+	Delete(ctx context.Context, receiptHandle string) error
+	DeleteBatch(ctx context.Context, receiptHandles []string) error
+	// ExtendVisibility keeps a received message hidden for another timeout from now,
+	// for deliveries which take longer than the visibility timeout
+	ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error
+}
+
+// New builds the incident events queue selected by Config.QueueDriver, sqs being the default.
+func New(cfg *config.Creds, db *gorm.DB, redisClient *redis.Client) (Queue, error) {
+	return newQueue(cfg, db, redisClient, incidentEventsQueue, cfg.AwsQueueUrl)
+}
+
+// NewSubscriberQueue builds the queue of status page updates for subscribers, it is kept apart from the
+// incident events so that a large fan-out never delays on-call alerts
+func NewSubscriberQueue(cfg *config.Creds, db *gorm.DB, redisClient *redis.Client) (Queue, error) {
+	return newQueue(cfg, db, redisClient, subscriberNotificationsQueue, cfg.AwsSubscriberQueueUrl)
+}
+
+func newQueue(cfg *config.Creds, db *gorm.DB, redisClient *redis.Client, name, sqsQueueURL string) (Queue, error) {
+	switch cfg.QueueDriver {
+	case DriverSQS, "":
+		return NewSQSQueue(cfg, sqsQueueURL), nil
+	case DriverRedis:
+		if redisClient == nil {
+			return nil, fmt.Errorf("redis client is required for the %s queue driver", DriverRedis)
+		}
+		return NewRedisQueue(redisClient, name), nil
+	case DriverPostgres:
+		return NewPostgresQueue(db, name), nil
+	}
+	return nil, fmt.Errorf("unsupported queue driver: %s", cfg.QueueDriver)
+}
+
+// batchSize caps the requested batch size to [1, MaxBatchSize]
+func batchSize(max int) int {
+	if max < 1 {
+		return 1
+	}
+	if max > MaxBatchSize {
+		return MaxBatchSize
+	}
+	return max
+}
+
+// sleep waits for d, returning false if the context is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
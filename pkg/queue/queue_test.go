@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testQueueSemantics checks the at-least-once contract of Queue which every driver has to follow: received
+// messages stay hidden for the visibility timeout, are delivered again unless deleted and ExtendVisibility
+// keeps them hidden for longer. visibility is the visibility timeout of q, q has to be empty.
+func testQueueSemantics(t *testing.T, q Queue, visibility time.Duration) {
+	ctx := context.Background()
+
+	messages, err := q.Receive(ctx, MaxBatchSize)
+	if err != nil || len(messages) != 0 {
+		t.Fatalf("empty queue: Receive = %d messages, %v", len(messages), err)
+	}
+
+	body := []byte("a")
+	for _, b := range [][]byte{body, []byte("b"), []byte("c")} {
+		if err := q.Send(ctx, b); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+	//the queue keeps its own copy of the body
+	body[0] = 'x'
+
+	received := receiveAll(t, q, 3)
+	if got := bodies(received); got != "[a b c]" {
+		t.Fatalf("received %s, want [a b c]", got)
+	}
+
+	//received messages are hidden from other consumers
+	if messages, _ := q.Receive(ctx, MaxBatchSize); len(messages) != 0 {
+		t.Fatalf("received %s while the messages should be invisible", bodies(messages))
+	}
+
+	if err := q.Delete(ctx, received[0].ReceiptHandle); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := q.DeleteBatch(ctx, []string{received[1].ReceiptHandle}); err != nil {
+		t.Fatalf("DeleteBatch returned error: %v", err)
+	}
+	if err := q.ExtendVisibility(ctx, received[2].ReceiptHandle, 3*visibility); err != nil {
+		t.Fatalf("ExtendVisibility returned error: %v", err)
+	}
+
+	//deleted messages are gone and the extended one is still hidden after the visibility timeout
+	time.Sleep(visibility + visibility/2)
+	if messages, _ := q.Receive(ctx, MaxBatchSize); len(messages) != 0 {
+		t.Fatalf("received %s after deleting/extending every message", bodies(messages))
+	}
+
+	//a message which is not deleted is delivered again once its visibility runs out
+	time.Sleep(2 * visibility)
+	redelivered := receiveAll(t, q, 1)
+	if got := bodies(redelivered); got != "[c]" {
+		t.Fatalf("redelivered %s, want [c]", got)
+	}
+	if err := q.Delete(ctx, redelivered[0].ReceiptHandle); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	time.Sleep(visibility + visibility/2)
+	if messages, _ := q.Receive(ctx, MaxBatchSize); len(messages) != 0 {
+		t.Fatalf("received %s after deleting the redelivered message", bodies(messages))
+	}
+}
+
+// receiveAll receives until want messages arrived, failing the test if they do not
+func receiveAll(t *testing.T, q Queue, want int) []*Message {
+	t.Helper()
+
+	var received []*Message
+	for attempt := 0; attempt < 5 && len(received) < want; attempt++ {
+		messages, err := q.Receive(context.Background(), MaxBatchSize)
+		if err != nil {
+			t.Fatalf("Receive returned error: %v", err)
+		}
+		received = append(received, messages...)
+	}
+	if len(received) != want {
+		t.Fatalf("received %d messages, want %d", len(received), want)
+	}
+	return received
+}
+
+func bodies(messages []*Message) string {
+	var out []string
+	for _, message := range messages {
+		out = append(out, string(message.Body))
+	}
+	return fmt.Sprint(out)
+}
+
+func TestBatchSize(t *testing.T) {
+	tests := map[int]int{-1: 1, 0: 1, 1: 1, 5: 5, MaxBatchSize: MaxBatchSize, MaxBatchSize + 1: MaxBatchSize}
+	for max, want := range tests {
+		if got := batchSize(max); got != want {
+			t.Errorf("batchSize(%d) = %d, want %d", max, got, want)
+		}
+	}
+}
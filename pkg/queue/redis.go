@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisConsumerGroup = "notification-job"
+	redisBodyField     = "body"
+)
+
+// redisQueue is a redis stream read through a consumer group, entries stay pending until
+// they are acked and pending entries idle for longer than the visibility timeout are claimed again
+type redisQueue struct {
+	client    *redis.Client
+	stream    string
+	consumer  string
+	groupOnce sync.Once
+	groupErr  error
+}
+
+func NewRedisQueue(client *redis.Client, stream string) *redisQueue {
+	hostname, _ := os.Hostname()
+
+	return &redisQueue{
+		client:   client,
+		stream:   stream,
+		consumer: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+func (rq *redisQueue) ensureGroup(ctx context.Context) error {
+	rq.groupOnce.Do(func() {
+		err := rq.client.XGroupCreateMkStream(ctx, rq.stream, redisConsumerGroup, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			rq.groupErr = err
+		}
+	})
+	return rq.groupErr
+}
+
+func (rq *redisQueue) Send(ctx context.Context, body []byte) error {
+	return rq.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: rq.stream,
+		Values: map[string]interface{}{redisBodyField: string(body)},
+	}).Err()
+}
+
+func (rq *redisQueue) Receive(ctx context.Context, max int) ([]*Message, error) {
+	err := rq.ensureGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	count := int64(batchSize(max))
+
+	//messages of consumers which died (or were too slow) are redelivered first
+	claimed, _, err := rq.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   rq.stream,
+		Group:    redisConsumerGroup,
+		Consumer: rq.consumer,
+		MinIdle:  VisibilityTimeout,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(claimed) != 0 {
+		return rq.toMessages(claimed), nil
+	}
+
+	streams, err := rq.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    redisConsumerGroup,
+		Consumer: rq.consumer,
+		Streams:  []string{rq.stream, ">"},
+		Count:    count,
+		Block:    waitTime,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(streams) == 0 {
+		return nil, nil
+	}
+	return rq.toMessages(streams[0].Messages), nil
+}
+
+func (rq *redisQueue) toMessages(msgs []redis.XMessage) []*Message {
+	messages := make([]*Message, 0, len(msgs))
+	for _, msg := range msgs {
+		body, _ := msg.Values[redisBodyField].(string)
+		messages = append(messages, &Message{Body: []byte(body), ReceiptHandle: msg.ID})
+	}
+	return messages
+}
+
+func (rq *redisQueue) Delete(ctx context.Context, receiptHandle string) error {
+	return rq.DeleteBatch(ctx, []string{receiptHandle})
+}
+
+func (rq *redisQueue) DeleteBatch(ctx context.Context, receiptHandles []string) error {
+	if len(receiptHandles) == 0 {
+		return nil
+	}
+	err := rq.client.XAck(ctx, rq.stream, redisConsumerGroup, receiptHandles...).Err()
+	if err != nil {
+		return err
+	}
+	return rq.client.XDel(ctx, rq.stream, receiptHandles...).Err()
+}
+
+// ExtendVisibility claims the entry again for this consumer, which resets its idle time. Pending entries
+// are reclaimed by idle time, so the entry stays hidden for VisibilityTimeout whatever the timeout is.
+func (rq *redisQueue) ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	return rq.client.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   rq.stream,
+		Group:    redisConsumerGroup,
+		Consumer: rq.consumer,
+		MinIdle:  0,
+		Messages: []string{receiptHandle},
+	}).Err()
+}
@@ -0,0 +1,71 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	config "github.com/ankur12345678/uptime-monitor/Config"
+	"github.com/ankur12345678/uptime-monitor/pkg/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+type sqsQueue struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+func NewSQSQueue(cfg *config.Creds, queueURL string) *sqsQueue {
+	awsConfig := aws.LoadAWSConfig(cfg.AwsProfile, cfg.AwsRegion)
+
+	return &sqsQueue{
+		client:   aws.NewClient(awsConfig),
+		queueURL: queueURL,
+	}
+}
+
+func (sq *sqsQueue) Send(ctx context.Context, body []byte) error {
+	return aws.SendMessage(ctx, sq.client, sq.queueURL, string(body))
+}
+
+func (sq *sqsQueue) Receive(ctx context.Context, max int) ([]*Message, error) {
+	msgs, err := aws.ReceiveMessages(ctx, sq.client, sq.queueURL, int32(batchSize(max)))
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, 0, len(msgs))
+	for _, msg := range msgs {
+		message := &Message{}
+		if msg.Body != nil {
+			message.Body = []byte(*msg.Body)
+		}
+		if msg.ReceiptHandle != nil {
+			message.ReceiptHandle = *msg.ReceiptHandle
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (sq *sqsQueue) Delete(ctx context.Context, receiptHandle string) error {
+	return aws.DeleteMessage(ctx, sq.client, sq.queueURL, &receiptHandle)
+}
+
+// DeleteBatch deletes in chunks of MaxBatchSize, the most sqs accepts per call
+func (sq *sqsQueue) DeleteBatch(ctx context.Context, receiptHandles []string) error {
+	for start := 0; start < len(receiptHandles); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(receiptHandles) {
+			end = len(receiptHandles)
+		}
+		err := aws.DeleteMessageBatch(ctx, sq.client, sq.queueURL, receiptHandles[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sq *sqsQueue) ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	return aws.ChangeMessageVisibility(ctx, sq.client, sq.queueURL, receiptHandle, timeout)
+}
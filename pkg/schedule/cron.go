@@ -0,0 +1,144 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	monthNames   = map[string]int{"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6, "JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12}
+	weekdayNames = map[string]int{"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6}
+)
+
+// cronSchedule is a standard "minute hour day-of-month month day-of-week" expression, a window
+// starts at every matching minute
+type cronSchedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek []bool
+	//as in cron, when both day fields are restricted a day matching either of them is a match
+	daysOfMonthRestricted, daysOfWeekRestricted bool
+}
+
+func parseCron(expression string) (*cronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression should have 5 fields, got %d", len(fields))
+	}
+
+	var (
+		cs  cronSchedule
+		err error
+	)
+	if cs.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if cs.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if cs.daysOfMonth, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if cs.months, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	//7 is sunday as well
+	if cs.daysOfWeek, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, err
+	}
+	cs.daysOfWeek[0] = cs.daysOfWeek[0] || cs.daysOfWeek[7]
+
+	cs.daysOfMonthRestricted = fields[2] != "*"
+	cs.daysOfWeekRestricted = fields[4] != "*"
+	return &cs, nil
+}
+
+// parseCronField supports *, single values, a-b ranges, lists and /step on * or ranges
+func parseCronField(field string, min, max int, names map[string]int) ([]bool, error) {
+	allowed := make([]bool, max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i != -1 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", field)
+			}
+			part = part[:i]
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], names); err != nil {
+				return nil, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseCronValue(bounds[1], names); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				//"5/15" means from 5 to the end in steps of 15
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("%q is out of range %d-%d", field, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			allowed[value] = true
+		}
+	}
+	return allowed, nil
+}
+
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if number, ok := names[strings.ToUpper(value)]; ok {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return number, nil
+}
+
+func (cs *cronSchedule) matchesDay(t time.Time) bool {
+	if !cs.months[int(t.Month())] {
+		return false
+	}
+
+	dayOfMonth, dayOfWeek := cs.daysOfMonth[t.Day()], cs.daysOfWeek[int(t.Weekday())]
+	if cs.daysOfMonthRestricted && cs.daysOfWeekRestricted {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
+
+// Occurrence walks back minute by minute from now, skipping whole days and hours which can not match
+func (cs *cronSchedule) Occurrence(startsAt time.Time, duration time.Duration, now time.Time) (time.Time, bool) {
+	var (
+		loc      = startsAt.Location()
+		earliest = now.Add(-duration)
+		t        = now.In(loc)
+	)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+
+	for t.After(earliest) && !t.Before(startsAt) {
+		switch {
+		case !cs.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !cs.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+		case cs.minutes[t.Minute()]:
+			return t, true
+		default:
+			t = t.Add(-time.Minute)
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,144 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// rruleSchedule is the subset of RFC 5545 RRULEs maintenance windows need: FREQ=DAILY|WEEKLY|MONTHLY
+// with INTERVAL, BYDAY (weekly), BYMONTHDAY (monthly) and UNTIL. Occurrences start at the time of day
+// of the window's start, DTSTART is the window's start itself.
+type rruleSchedule struct {
+	freq        string
+	interval    int
+	weekdays    map[time.Weekday]bool
+	monthDays   map[int]bool
+	until       *time.Time
+	hasWeekdays bool
+}
+
+func parseRRule(rule string) (*rruleSchedule, error) {
+	rs := rruleSchedule{interval: 1, weekdays: map[time.Weekday]bool{}, monthDays: map[int]bool{}}
+
+	for _, part := range strings.Split(strings.TrimSuffix(rule, ";"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" && value != "MONTHLY" {
+				return nil, fmt.Errorf("unsupported rrule frequency %q", value)
+			}
+			rs.freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid rrule interval %q", value)
+			}
+			rs.interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("invalid rrule day %q", day)
+				}
+				rs.weekdays[weekday] = true
+			}
+			rs.hasWeekdays = true
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay < 1 || monthDay > 31 {
+					return nil, fmt.Errorf("invalid rrule month day %q", day)
+				}
+				rs.monthDays[monthDay] = true
+			}
+		case "UNTIL":
+			until, err := parseRRuleTime(value)
+			if err != nil {
+				return nil, err
+			}
+			rs.until = &until
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if rs.freq == "" {
+		return nil, fmt.Errorf("rrule is missing FREQ")
+	}
+	if rs.hasWeekdays && rs.freq != "WEEKLY" {
+		return nil, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if len(rs.monthDays) != 0 && rs.freq != "MONTHLY" {
+		return nil, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return &rs, nil
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid rrule UNTIL %q", value)
+}
+
+func (rs *rruleSchedule) matchesDay(startsAt, day time.Time) bool {
+	switch rs.freq {
+	case "DAILY":
+		return (civilDay(day)-civilDay(startsAt))%rs.interval == 0
+	case "WEEKLY":
+		if (rs.hasWeekdays && !rs.weekdays[day.Weekday()]) || (!rs.hasWeekdays && day.Weekday() != startsAt.Weekday()) {
+			return false
+		}
+		//weeks start on monday, as the RRULE default WKST
+		weekStart := func(t time.Time) int { return civilDay(t) - (int(t.Weekday())+6)%7 }
+		return ((weekStart(day)-weekStart(startsAt))/7)%rs.interval == 0
+	case "MONTHLY":
+		if (len(rs.monthDays) != 0 && !rs.monthDays[day.Day()]) || (len(rs.monthDays) == 0 && day.Day() != startsAt.Day()) {
+			return false
+		}
+		months := (day.Year()-startsAt.Year())*12 + int(day.Month()) - int(startsAt.Month())
+		return months%rs.interval == 0
+	}
+	return false
+}
+
+// Occurrence checks the days which can hold an occurrence covering now, latest first
+func (rs *rruleSchedule) Occurrence(startsAt time.Time, duration time.Duration, now time.Time) (time.Time, bool) {
+	var (
+		loc      = startsAt.Location()
+		earliest = now.Add(-duration)
+		midnight = func(t time.Time) time.Time {
+			t = t.In(loc)
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		firstDay = midnight(earliest)
+	)
+
+	for day := midnight(now); !day.Before(firstDay); day = day.AddDate(0, 0, -1) {
+		start := time.Date(day.Year(), day.Month(), day.Day(), startsAt.Hour(), startsAt.Minute(), startsAt.Second(), 0, loc)
+		if start.After(now) || !start.After(earliest) || start.Before(startsAt) {
+			continue
+		}
+		if rs.until != nil && start.After(*rs.until) {
+			continue
+		}
+		if rs.matchesDay(startsAt, day) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,39 @@
+// Package schedule evaluates the recurrence of maintenance windows, either a 5 field cron expression
+// ("0 2 * * SUN") or an RRULE ("FREQ=WEEKLY;BYDAY=SU"). Both are evaluated in the location of the
+// start time they are given, so "02:00 every sunday" stays 02:00 across DST changes.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	//windows name their timezone, the embedded database keeps them working on hosts without tzdata
+	_ "time/tzdata"
+)
+
+// Schedule yields the occurrences of a recurring window
+type Schedule interface {
+	// Occurrence returns the start of the latest occurrence within (now-duration, now], occurrences
+	// before startsAt are skipped. startsAt also provides the location and, for RRULEs, the time of day.
+	Occurrence(startsAt time.Time, duration time.Duration, now time.Time) (time.Time, bool)
+}
+
+// Parse validates the recurrence, RRULEs are recognised by their FREQ part
+func Parse(recurrence string) (Schedule, error) {
+	recurrence = strings.TrimSpace(recurrence)
+	if recurrence == "" {
+		return nil, fmt.Errorf("empty recurrence")
+	}
+
+	upper := strings.ToUpper(recurrence)
+	if strings.HasPrefix(upper, "RRULE:") || strings.Contains(upper, "FREQ=") {
+		return parseRRule(strings.TrimPrefix(upper, "RRULE:"))
+	}
+	return parseCron(recurrence)
+}
+
+// civilDay counts days since the epoch for the calendar date of t, ignoring its clock and offset
+func civilDay(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
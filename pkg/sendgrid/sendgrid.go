@@ -2,6 +2,7 @@ package sendgrid
 
 import (
 	"bytes"
+	"fmt"
 	"text/template"
 
 	config "github.com/ankur12345678/uptime-monitor/Config"
@@ -66,6 +67,8 @@ const emailTemplate = `
         This is an automated message to inform you that the website at
         <a href="{{.WebsiteURL}}" class="highlight">{{.WebsiteURL}}</a> is currently in
         <span class="highlight">{{.Status}}</span> status.<br /><br />
+        {{if .Reason}}Reason: <span class="highlight">{{.Reason}}</span><br /><br />{{end}}
+        {{if .AckURL}}<a href="{{.AckURL}}">Acknowledge this incident</a> to stop further escalations.<br /><br />{{end}}
         Please take appropriate action if needed.
       </div>
       <div class="footer">
@@ -83,20 +86,168 @@ Website Status Update
 Hello,
 
 This is an automated message to inform you that the website at {{.WebsiteURL}} is currently in {{.Status}} status.
-
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}{{if .AckURL}}
+Acknowledge this incident to stop further escalations: {{.AckURL}}
+{{end}}
 Please take appropriate action if needed.
 
 © {{.Year}} Uptime Mon8or. All rights reserved.
 `
 
+const verificationTemplate = `
+<!DOCTYPE html>
+<html lang="en">
+  <body style="font-family: Arial, sans-serif; background-color: #f6f9fc;">
+    <div style="max-width: 600px; margin: 40px auto; background-color: #ffffff; padding: 30px; border-radius: 8px;">
+      <div style="font-size: 22px; font-weight: bold; color: #333333; margin-bottom: 20px;">Confirm Alert Email</div>
+      <div style="font-size: 16px; color: #555555; line-height: 1.6;">
+        Hello,<br /><br />
+        This address was added to receive status alerts for
+        <a href="{{.WebsiteURL}}" style="color: #007bff;">{{.WebsiteURL}}</a>.<br /><br />
+        Please <a href="{{.Link}}" style="color: #007bff;">confirm this email</a> to start receiving alerts.
+        If you did not expect this, you can ignore this email.
+      </div>
+      <div style="margin-top: 30px; font-size: 13px; color: #999999; text-align: center;">
+        &copy; {{.Year}} Uptime Mon8or. All rights reserved.
+      </div>
+    </div>
+  </body>
+</html>
+`
+
+const verificationPlainTextTemplate = `
+Confirm Alert Email
+
+Hello,
+
+This address was added to receive status alerts for {{.WebsiteURL}}.
+
+Please confirm this email to start receiving alerts: {{.Link}}
+If you did not expect this, you can ignore this email.
+
+© {{.Year}} Uptime Mon8or. All rights reserved.
+`
+
+const subscriptionConfirmationTemplate = `
+<!DOCTYPE html>
+<html lang="en">
+  <body style="font-family: Arial, sans-serif; background-color: #f6f9fc;">
+    <div style="max-width: 600px; margin: 40px auto; background-color: #ffffff; padding: 30px; border-radius: 8px;">
+      <div style="font-size: 22px; font-weight: bold; color: #333333; margin-bottom: 20px;">Confirm Your Subscription</div>
+      <div style="font-size: 16px; color: #555555; line-height: 1.6;">
+        Hello,<br /><br />
+        This address was subscribed to incident updates of
+        <a href="{{.PageURL}}" style="color: #007bff;">{{.PageTitle}}</a>.<br /><br />
+        Please <a href="{{.Link}}" style="color: #007bff;">confirm your subscription</a> to start receiving updates.
+        If you did not subscribe, you can ignore this email.
+      </div>
+      <div style="margin-top: 30px; font-size: 13px; color: #999999; text-align: center;">
+        &copy; {{.Year}} Uptime Mon8or. All rights reserved.
+      </div>
+    </div>
+  </body>
+</html>
+`
+
+const subscriptionConfirmationPlainTextTemplate = `
+Confirm Your Subscription
+
+Hello,
+
+This address was subscribed to incident updates of {{.PageTitle}} ({{.PageURL}}).
+
+Please confirm your subscription to start receiving updates: {{.Link}}
+If you did not subscribe, you can ignore this email.
+
+© {{.Year}} Uptime Mon8or. All rights reserved.
+`
+
+const subscriberUpdateTemplate = `
+<!DOCTYPE html>
+<html lang="en">
+  <body style="font-family: Arial, sans-serif; background-color: #f6f9fc;">
+    <div style="max-width: 600px; margin: 40px auto; background-color: #ffffff; padding: 30px; border-radius: 8px;">
+      <div style="font-size: 22px; font-weight: bold; color: #333333; margin-bottom: 20px;">{{.PageTitle}} Status Update</div>
+      <div style="font-size: 16px; color: #555555; line-height: 1.6;">
+        {{if .Resolved}}<b>{{.Component}}</b> is operational again, the incident was resolved.
+        {{else}}<b>{{.Component}}</b> is experiencing an outage, we are looking into it.{{end}}<br /><br />
+        Follow the current status on <a href="{{.PageURL}}" style="color: #007bff;">our status page</a>.
+      </div>
+      <div style="margin-top: 30px; font-size: 13px; color: #999999; text-align: center;">
+        You are receiving this because you subscribed to {{.PageTitle}} updates.
+        <a href="{{.UnsubscribeURL}}" style="color: #999999;">Unsubscribe</a><br />
+        &copy; {{.Year}} Uptime Mon8or. All rights reserved.
+      </div>
+    </div>
+  </body>
+</html>
+`
+
+const subscriberUpdatePlainTextTemplate = `
+{{.PageTitle}} Status Update
+
+{{if .Resolved}}{{.Component}} is operational again, the incident was resolved.{{else}}{{.Component}} is experiencing an outage, we are looking into it.{{end}}
+
+Follow the current status on our status page: {{.PageURL}}
+
+You are receiving this because you subscribed to {{.PageTitle}} updates.
+Unsubscribe: {{.UnsubscribeURL}}
+
+© {{.Year}} Uptime Mon8or. All rights reserved.
+`
+
+// replaced by sendgrid with the link of each recipient, so a whole batch shares one rendered body
+const unsubscribeURLTag = "-unsubscribe_url-"
+
+type LinkEmailData struct {
+	WebsiteURL string
+	Link       string
+	Year       int
+}
+
+type SubscriptionEmailData struct {
+	PageTitle string
+	PageURL   string
+	Link      string
+	Year      int
+}
+
+type SubscriberUpdateEmailData struct {
+	PageTitle string
+	PageURL   string
+	Component string
+	Resolved  bool
+	//filled in by SendSubscriberUpdates
+	UnsubscribeURL string
+	Year           int
+}
+
+// SubscriberRecipient is a single address of a subscriber update batch
+type SubscriberRecipient struct {
+	Email          string
+	UnsubscribeURL string
+}
+
 type EmailData struct {
 	WebsiteURL string
 	Status     string
+	Reason     string
+	AckURL     string
 	Year       int
 }
 
 func RenderHTMLBody(data EmailData) (string, error) {
-	tmpl, err := template.New("email").Parse(emailTemplate)
+	return renderTemplate("email", emailTemplate, data)
+}
+
+func RenderPlainTextBody(data EmailData) (string, error) {
+	return renderTemplate("plain", plainTextTemplate, data)
+}
+
+func renderTemplate(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
 	if err != nil {
 		return "", err
 	}
@@ -110,19 +261,79 @@ func RenderHTMLBody(data EmailData) (string, error) {
 	return buf.String(), nil
 }
 
-func RenderPlainTextBody(data EmailData) (string, error) {
-	tmpl, err := template.New("plain").Parse(plainTextTemplate)
+// SendVerificationEmail asks the owner of an alert email to confirm it through data.Link
+func SendVerificationEmail(toEmail string, cfg *config.Creds, data LinkEmailData) error {
+	htmlBody, err := renderTemplate("verification", verificationTemplate, data)
 	if err != nil {
-		return "", err
+		logger.Error("error in preparing verification email from html template | err: ", err)
+		return err
 	}
 
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, data)
+	plainText, err := renderTemplate("verification_plain", verificationPlainTextTemplate, data)
 	if err != nil {
-		return "", err
+		logger.Error("error in preparing verification email from plain template | err: ", err)
+		return err
 	}
 
-	return buf.String(), nil
+	return send(toEmail, "", "Confirm your alert email", plainText, htmlBody, cfg)
+}
+
+// SendSubscriptionConfirmationEmail asks a status page subscriber to confirm the subscription through data.Link
+func SendSubscriptionConfirmationEmail(toEmail string, cfg *config.Creds, data SubscriptionEmailData) error {
+	htmlBody, err := renderTemplate("subscription_confirmation", subscriptionConfirmationTemplate, data)
+	if err != nil {
+		logger.Error("error in preparing subscription email from html template | err: ", err)
+		return err
+	}
+
+	plainText, err := renderTemplate("subscription_confirmation_plain", subscriptionConfirmationPlainTextTemplate, data)
+	if err != nil {
+		logger.Error("error in preparing subscription email from plain template | err: ", err)
+		return err
+	}
+
+	return send(toEmail, "", "Confirm your subscription to "+data.PageTitle, plainText, htmlBody, cfg)
+}
+
+// SendSubscriberUpdates sends one update to a batch of subscribers in a single request, every recipient
+// gets a personalization with their own unsubscribe link
+func SendSubscriberUpdates(recipients []SubscriberRecipient, cfg *config.Creds, data SubscriberUpdateEmailData) error {
+	data.UnsubscribeURL = unsubscribeURLTag
+
+	htmlBody, err := renderTemplate("subscriber_update", subscriberUpdateTemplate, data)
+	if err != nil {
+		logger.Error("error in preparing subscriber email from html template | err: ", err)
+		return err
+	}
+
+	plainText, err := renderTemplate("subscriber_update_plain", subscriberUpdatePlainTextTemplate, data)
+	if err != nil {
+		logger.Error("error in preparing subscriber email from plain template | err: ", err)
+		return err
+	}
+
+	message := mail.NewV3Mail()
+	message.SetFrom(mail.NewEmail(cfg.ServiceName, cfg.SendgridFromEmail))
+	message.Subject = data.PageTitle + " Status Update"
+	message.AddContent(mail.NewContent("text/plain", plainText), mail.NewContent("text/html", htmlBody))
+	for _, recipient := range recipients {
+		personalization := mail.NewPersonalization()
+		personalization.AddTos(mail.NewEmail("", recipient.Email))
+		personalization.SetSubstitution(unsubscribeURLTag, recipient.UnsubscribeURL)
+		personalization.SetHeader("List-Unsubscribe", "<"+recipient.UnsubscribeURL+">")
+		personalization.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+		message.AddPersonalizations(personalization)
+	}
+
+	client := sendgrid.NewSendClient(cfg.SendgridApiKey)
+	resp, err := client.Send(message)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sendgrid responded with status %d: %s", resp.StatusCode, resp.Body)
+	}
+	return nil
 }
 
 func SendEmail(toEmail, toName string, cfg *config.Creds, data EmailData) error {
@@ -139,9 +350,13 @@ func SendEmail(toEmail, toName string, cfg *config.Creds, data EmailData) error
 		return err
 	}
 
+	return send(toEmail, toName, "Webiste Status Update", plainText, htmlBody, cfg)
+}
+
+func send(toEmail, toName, subject, plainText, htmlBody string, cfg *config.Creds) error {
 	from := mail.NewEmail(cfg.ServiceName, cfg.SendgridFromEmail)
 	to := mail.NewEmail(toName, toEmail)
-	message := mail.NewSingleEmail(from, "Webiste Status Update", to, plainText, htmlBody)
+	message := mail.NewSingleEmail(from, subject, to, plainText, htmlBody)
 
 	client := sendgrid.NewSendClient(cfg.SendgridApiKey)
 	resp, err := client.Send(message)
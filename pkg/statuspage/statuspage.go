@@ -0,0 +1,164 @@
+// Package statuspage holds the public view of a status page and renders it as html, the json
+// variant is the same Page encoded as is.
+package statuspage
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+const (
+	StatusOperational   = "operational"
+	StatusDegraded      = "degraded"
+	StatusOutage        = "outage"
+	StatusNoData        = "no_data"
+	StatusPartialOutage = "partial_outage"
+	StatusMajorOutage   = "major_outage"
+
+	DefaultAccentColor = "#2f855a"
+
+	//a day below these uptimes shows as degraded/an outage in the uptime bars
+	degradedBelowPercentage = 99.0
+	outageBelowPercentage   = 95.0
+)
+
+// Page is everything shown on a status page, it is cached as json so it only holds public details
+type Page struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	LogoURL     string     `json:"logo_url,omitempty"`
+	AccentColor string     `json:"accent_color"`
+	Status      string     `json:"status"`
+	Websites    []Website  `json:"websites"`
+	Incidents   []Incident `json:"incidents"`
+	GeneratedAt time.Time  `json:"generated_at"`
+}
+
+type Website struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	//over all the days of the history
+	UptimePercentage float64 `json:"uptime_percentage"`
+	Days             []Day   `json:"days"`
+}
+
+// Day is a single uptime bar, oldest first
+type Day struct {
+	Date             string  `json:"date"`
+	TotalChecks      int64   `json:"total_checks"`
+	UptimePercentage float64 `json:"uptime_percentage"`
+	Status           string  `json:"status"`
+}
+
+type Incident struct {
+	Website           string     `json:"website"`
+	State             string     `json:"state"`
+	StartedAt         time.Time  `json:"started_at"`
+	ResolvedAt        *time.Time `json:"resolved_at"`
+	DurationInSeconds int64      `json:"duration_in_seconds"`
+}
+
+// DayStatus is the colour of an uptime bar
+func DayStatus(totalChecks int64, uptimePercentage float64) string {
+	switch {
+	case totalChecks == 0:
+		return StatusNoData
+	case uptimePercentage < outageBelowPercentage:
+		return StatusOutage
+	case uptimePercentage < degradedBelowPercentage:
+		return StatusDegraded
+	}
+	return StatusOperational
+}
+
+// OverallStatus is operational unless some (partial outage) or all (major outage) websites are down
+func OverallStatus(websites []Website) string {
+	down := 0
+	for _, website := range websites {
+		if website.Status == StatusOutage {
+			down++
+		}
+	}
+
+	switch {
+	case down == 0:
+		return StatusOperational
+	case down == len(websites):
+		return StatusMajorOutage
+	}
+	return StatusPartialOutage
+}
+
+var statusLabels = map[string]string{
+	StatusOperational:   "Operational",
+	StatusDegraded:      "Degraded",
+	StatusOutage:        "Outage",
+	StatusNoData:        "No data",
+	StatusPartialOutage: "Partial outage",
+	StatusMajorOutage:   "Major outage",
+}
+
+var pageTemplate = template.Must(template.New("status_page").Funcs(template.FuncMap{
+	"label": func(status string) string { return statusLabels[status] },
+	"datetime": func(t time.Time) string {
+		return t.UTC().Format("Jan 2, 2006 15:04 UTC")
+	},
+	"duration": func(seconds int64) string {
+		return (time.Duration(seconds) * time.Second).String()
+	},
+}).Parse(pageHTML))
+
+// Render writes the page as a standalone html document
+func Render(w io.Writer, page *Page) error {
+	return pageTemplate.Execute(w, page)
+}
+
+const pageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; background: #f7f7f8; color: #1a202c; }
+main { max-width: 860px; margin: 0 auto; padding: 32px 16px; }
+header { display: flex; align-items: center; gap: 16px; margin-bottom: 24px; }
+header img { max-height: 48px; }
+h1 { font-size: 24px; margin: 0; }
+.banner { color: #fff; border-radius: 6px; padding: 16px; font-weight: 600; margin-bottom: 24px; }
+.card { background: #fff; border: 1px solid #e2e8f0; border-radius: 6px; padding: 16px; margin-bottom: 16px; }
+.row { display: flex; justify-content: space-between; margin-bottom: 8px; }
+.bars { display: flex; gap: 2px; height: 32px; }
+.bar { flex: 1; border-radius: 2px; }
+.operational { background: #48bb78; } .degraded { background: #ecc94b; } .outage { background: #e53e3e; } .no_data { background: #cbd5e0; }
+.partial_outage { background: #dd6b20; } .major_outage { background: #c53030; }
+.muted { color: #718096; font-size: 13px; }
+</style>
+</head>
+<body>
+<main>
+<header>
+{{if .LogoURL}}<img src="{{.LogoURL}}" alt="{{.Title}}">{{end}}
+<div><h1>{{.Title}}</h1>{{if .Description}}<div class="muted">{{.Description}}</div>{{end}}</div>
+</header>
+{{if eq .Status "operational"}}<div class="banner" style="background: {{.AccentColor}}">All systems operational</div>
+{{else}}<div class="banner {{.Status}}">{{label .Status}}</div>{{end}}
+{{range .Websites}}<div class="card">
+<div class="row"><strong>{{.Name}}</strong><span>{{label .Status}}</span></div>
+<div class="bars">{{range .Days}}<div class="bar {{.Status}}" title="{{.Date}}: {{if .TotalChecks}}{{printf "%.2f" .UptimePercentage}}% uptime{{else}}no data{{end}}"></div>{{end}}</div>
+<div class="row muted"><span>{{len .Days}} days ago</span><span>{{printf "%.2f" .UptimePercentage}}% uptime</span><span>Today</span></div>
+</div>
+{{end}}
+<h2>Recent incidents</h2>
+{{range .Incidents}}<div class="card">
+<div class="row"><strong>{{.Website}}</strong><span>{{if .ResolvedAt}}Resolved{{else}}Ongoing{{end}}</span></div>
+<div class="muted">Started {{datetime .StartedAt}}{{if .ResolvedAt}}, resolved {{datetime .ResolvedAt}} after {{duration .DurationInSeconds}}{{end}}</div>
+</div>
+{{else}}<p class="muted">No incidents reported recently.</p>
+{{end}}
+<p class="muted">Updated {{datetime .GeneratedAt}}</p>
+</main>
+</body>
+</html>
+`
@@ -0,0 +1,179 @@
+package telephony
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/ankur12345678/uptime-monitor/Config"
+	"github.com/ankur12345678/uptime-monitor/pkg/logger"
+)
+
+const (
+	ProviderTwilio = "twilio"
+	ProviderFake   = "fake"
+
+	twilioBaseURL  = "https://api.twilio.com"
+	requestTimeout = 15 * time.Second
+
+	// the fake provider only keeps the latest messages, it may run in a long lived worker
+	maxFakeSent = 100
+)
+
+// ErrNotConfigured is returned by every send when no provider is configured, so that phone alerts
+// fail (and are retried) instead of being reported as delivered
+var ErrNotConfigured = errors.New("sms provider is not configured")
+
+// Provider sends sms and places voice calls to E.164 numbers
+type Provider interface {
+	SendSMS(ctx context.Context, to, body string) error
+	Call(ctx context.Context, to, message string) error
+}
+
+// NewProvider picks the provider configured in Config.SmsProvider, the fake one has to be selected explicitly
+func NewProvider(cfg *config.Creds) (Provider, error) {
+	switch cfg.SmsProvider {
+	case ProviderTwilio:
+		if cfg.TwilioAccountSid == "" || cfg.TwilioAuthToken == "" || cfg.TwilioFromNumber == "" {
+			return nil, errors.New("twilio account sid, auth token and from number are required")
+		}
+		return NewTwilioProvider(twilioBaseURL, cfg.TwilioAccountSid, cfg.TwilioAuthToken, cfg.TwilioFromNumber), nil
+	case ProviderFake:
+		logger.Info("using the fake sms provider, phone alerts are only logged")
+		return NewFakeProvider(), nil
+	case "":
+		logger.Info("no sms provider configured, phone alerts will fail")
+		return unconfiguredProvider{}, nil
+	}
+	return nil, fmt.Errorf("unsupported sms provider: %s", cfg.SmsProvider)
+}
+
+// unconfiguredProvider fails every send with ErrNotConfigured
+type unconfiguredProvider struct{}
+
+func (unconfiguredProvider) SendSMS(ctx context.Context, to, body string) error {
+	return ErrNotConfigured
+}
+
+func (unconfiguredProvider) Call(ctx context.Context, to, message string) error {
+	return ErrNotConfigured
+}
+
+// twilioProvider talks to the twilio REST api, baseURL is configurable so it can be pointed to a local server
+type twilioProvider struct {
+	client     *http.Client
+	baseURL    string
+	accountSid string
+	authToken  string
+	fromNumber string
+}
+
+func NewTwilioProvider(baseURL, accountSid, authToken, fromNumber string) *twilioProvider {
+	return &twilioProvider{
+		client:     &http.Client{Timeout: requestTimeout},
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		accountSid: accountSid,
+		authToken:  authToken,
+		fromNumber: fromNumber,
+	}
+}
+
+func (tp *twilioProvider) SendSMS(ctx context.Context, to, body string) error {
+	return tp.post(ctx, "Messages.json", url.Values{
+		"To":   {to},
+		"From": {tp.fromNumber},
+		"Body": {body},
+	})
+}
+
+func (tp *twilioProvider) Call(ctx context.Context, to, message string) error {
+	var escaped strings.Builder
+	err := xml.EscapeText(&escaped, []byte(message))
+	if err != nil {
+		return err
+	}
+
+	//the message is read out twice in case the first one is missed while picking up
+	twiml := fmt.Sprintf("<Response><Say>%s</Say><Pause length=\"1\"/><Say>%s</Say></Response>", escaped.String(), escaped.String())
+	return tp.post(ctx, "Calls.json", url.Values{
+		"To":    {to},
+		"From":  {tp.fromNumber},
+		"Twiml": {twiml},
+	})
+}
+
+func (tp *twilioProvider) post(ctx context.Context, resource string, form url.Values) error {
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/%s", tp.baseURL, tp.accountSid, resource)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(tp.accountSid, tp.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := tp.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("twilio responded with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Sent is a message recorded by the fake provider
+type Sent struct {
+	Kind string //sms or call
+	To   string
+	Body string
+}
+
+// FakeProvider only records what would have been sent (the latest maxFakeSent), for local runs and tests
+type FakeProvider struct {
+	mu   sync.Mutex
+	sent []Sent
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (fp *FakeProvider) SendSMS(ctx context.Context, to, body string) error {
+	fp.record(Sent{Kind: "sms", To: to, Body: body})
+	return nil
+}
+
+func (fp *FakeProvider) Call(ctx context.Context, to, message string) error {
+	fp.record(Sent{Kind: "call", To: to, Body: message})
+	return nil
+}
+
+func (fp *FakeProvider) record(sent Sent) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	logger.Infof("fake telephony provider | %s to %s: %s", sent.Kind, sent.To, sent.Body)
+	if len(fp.sent) == maxFakeSent {
+		fp.sent = append(fp.sent[:0], fp.sent[1:]...)
+	}
+	fp.sent = append(fp.sent, sent)
+}
+
+// Sent returns a copy of everything recorded so far
+func (fp *FakeProvider) Sent() []Sent {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	return append([]Sent(nil), fp.sent...)
+}
@@ -0,0 +1,142 @@
+package telephony
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	config "github.com/ankur12345678/uptime-monitor/Config"
+)
+
+func TestNewProvider(t *testing.T) {
+	twilio := &config.Creds{SmsProvider: ProviderTwilio, TwilioAccountSid: "AC123", TwilioAuthToken: "token", TwilioFromNumber: "+14155550100"}
+
+	if provider, err := NewProvider(twilio); err != nil {
+		t.Errorf("twilio: unexpected error %v", err)
+	} else if _, ok := provider.(*twilioProvider); !ok {
+		t.Errorf("twilio: got %T", provider)
+	}
+
+	if provider, err := NewProvider(&config.Creds{SmsProvider: ProviderFake}); err != nil {
+		t.Errorf("fake: unexpected error %v", err)
+	} else if _, ok := provider.(*FakeProvider); !ok {
+		t.Errorf("fake: got %T", provider)
+	}
+
+	provider, err := NewProvider(&config.Creds{})
+	if err != nil {
+		t.Fatalf("unconfigured: unexpected error %v", err)
+	}
+	if err := provider.SendSMS(context.Background(), "+14155552671", "down"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("unconfigured SendSMS = %v, want ErrNotConfigured", err)
+	}
+	if err := provider.Call(context.Background(), "+14155552671", "down"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("unconfigured Call = %v, want ErrNotConfigured", err)
+	}
+
+	for _, name := range []string{"Twilio", "twillio", "sms"} {
+		if _, err := NewProvider(&config.Creds{SmsProvider: name}); err == nil {
+			t.Errorf("%q: expected an error for an unknown provider", name)
+		}
+	}
+	if _, err := NewProvider(&config.Creds{SmsProvider: ProviderTwilio}); err == nil {
+		t.Error("twilio without credentials: expected an error")
+	}
+}
+
+// twilioRequest is a request received by the fake twilio server
+type twilioRequest struct {
+	path     string
+	username string
+	password string
+	form     url.Values
+}
+
+func newTwilioServer(t *testing.T, status int) (*httptest.Server, <-chan twilioRequest) {
+	t.Helper()
+	requests := make(chan twilioRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		requests <- twilioRequest{path: r.URL.Path, username: username, password: password, form: form}
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"sid": "SM1"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestTwilioSendSMS(t *testing.T) {
+	server, requests := newTwilioServer(t, http.StatusCreated)
+	provider := NewTwilioProvider(server.URL+"/", "AC123", "token", "+14155550100")
+
+	err := provider.SendSMS(context.Background(), "+14155552671", "example.com is UNHEALTHY")
+	if err != nil {
+		t.Fatalf("SendSMS returned error: %v", err)
+	}
+
+	req := <-requests
+	if req.path != "/2010-04-01/Accounts/AC123/Messages.json" {
+		t.Errorf("path = %q", req.path)
+	}
+	if req.username != "AC123" || req.password != "token" {
+		t.Errorf("basic auth = %q:%q", req.username, req.password)
+	}
+	if req.form.Get("To") != "+14155552671" || req.form.Get("From") != "+14155550100" || req.form.Get("Body") != "example.com is UNHEALTHY" {
+		t.Errorf("form = %v", req.form)
+	}
+}
+
+func TestTwilioCallEscapesMessage(t *testing.T) {
+	server, requests := newTwilioServer(t, http.StatusCreated)
+	provider := NewTwilioProvider(server.URL, "AC123", "token", "+14155550100")
+
+	err := provider.Call(context.Background(), "+14155552671", "a&b <down>")
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+
+	req := <-requests
+	if req.path != "/2010-04-01/Accounts/AC123/Calls.json" {
+		t.Errorf("path = %q", req.path)
+	}
+	want := `<Response><Say>a&amp;b &lt;down&gt;</Say><Pause length="1"/><Say>a&amp;b &lt;down&gt;</Say></Response>`
+	if twiml := req.form.Get("Twiml"); twiml != want {
+		t.Errorf("Twiml = %q, want %q", twiml, want)
+	}
+}
+
+func TestTwilioErrorStatus(t *testing.T) {
+	server, _ := newTwilioServer(t, http.StatusBadRequest)
+	provider := NewTwilioProvider(server.URL, "AC123", "token", "+14155550100")
+
+	err := provider.SendSMS(context.Background(), "+14155552671", "down")
+	if err == nil {
+		t.Fatal("SendSMS should fail when twilio responds with 400")
+	}
+}
+
+func TestFakeProviderKeepsLatest(t *testing.T) {
+	provider := NewFakeProvider()
+	for i := 0; i < maxFakeSent+5; i++ {
+		err := provider.SendSMS(context.Background(), "+14155552671", fmt.Sprint(i))
+		if err != nil {
+			t.Fatalf("SendSMS returned error: %v", err)
+		}
+	}
+	_ = provider.Call(context.Background(), "+14155552671", "call")
+
+	sent := provider.Sent()
+	if len(sent) != maxFakeSent {
+		t.Fatalf("kept %d messages, want %d", len(sent), maxFakeSent)
+	}
+	if sent[0].Body != "6" || sent[len(sent)-1].Kind != "call" {
+		t.Errorf("unexpected messages kept: first %+v, last %+v", sent[0], sent[len(sent)-1])
+	}
+}
@@ -68,6 +68,18 @@ func isValidDOB(dob string) bool {
 	return age >= 18 && age <= 100
 }
 
+// IsE164PhoneNumber accepts only valid numbers already written in E.164 (+<country code><number>)
+func IsE164PhoneNumber(s string) bool {
+	if !strings.HasPrefix(s, "+") {
+		return false
+	}
+	num, err := phonenumbers.Parse(s, "")
+	if err != nil {
+		return false
+	}
+	return phonenumbers.IsValidNumber(num) && phonenumbers.Format(num, phonenumbers.E164) == s
+}
+
 func InitValidator() (*validator.Validate, ut.Translator, error) {
 	translator := en.New()
 	uni := ut.New(translator, translator)
@@ -440,6 +452,45 @@ func InitValidator() (*validator.Validate, ut.Translator, error) {
 		logger.Fatal("Unable to register required translator", err)
 	}
 
+	// e164phonenumber validation
+	err = v.RegisterValidation("e164phonenumber", func(fl validator.FieldLevel) bool {
+		return IsE164PhoneNumber(fl.Field().String())
+	})
+	if err != nil {
+		logger.Fatal("Unable to register required validator", err)
+	}
+
+	// e164phonenumber translation
+	err = v.RegisterTranslation("e164phonenumber", trans, func(ut ut.Translator) error {
+		return ut.Add("e164phonenumber", "{0} should be a valid phonenumber in E.164 format, eg. +14155552671", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("e164phonenumber", fe.Field())
+		return t
+	})
+	if err != nil {
+		logger.Fatal("Unable to register required translator", err)
+	}
+
+	// slug validation
+	err = v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		re := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+		return re.MatchString(fl.Field().String())
+	})
+	if err != nil {
+		logger.Fatal("Unable to register required validator", err)
+	}
+
+	// slug translation
+	err = v.RegisterTranslation("slug", trans, func(ut ut.Translator) error {
+		return ut.Add("slug", "{0} should only contain lowercase letters, digits and single hyphens, eg. acme-status", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("slug", fe.Field())
+		return t
+	})
+	if err != nil {
+		logger.Fatal("Unable to register required translator", err)
+	}
+
 	// currency validation
 	err = v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
 		//TODO: will be fetched in future from db or some other source
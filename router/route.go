@@ -15,11 +15,78 @@ func InitRoutes(ctrl *controllers.BaseController) {
 	v1RouteGroup.POST("/refresh", middlewares.HandleAuth, ctrl.HandleRefresh)
 	v1RouteGroup.POST("/logout", middlewares.HandleAuth, ctrl.HandleLogOut)
 
+	//opened from the confirmation email, the token is the authentication
+	v1RouteGroup.GET("/alert-targets/verify/:token", ctrl.VerifyAlertTarget)
+	//acknowledgement from the down email, the signature is the authentication. The link only renders a
+	//confirmation which is posted to acknowledge
+	v1RouteGroup.GET("/incidents/:id/ack", ctrl.ConfirmIncidentAckLink)
+	v1RouteGroup.POST("/incidents/:id/ack/confirm", ctrl.AcknowledgeIncidentFromLink)
+
+	//pinged by the jobs of heartbeat monitors, the token is the authentication
+	v1RouteGroup.GET("/ping/:token", ctrl.Ping)
+	v1RouteGroup.POST("/ping/:token", ctrl.Ping)
+	v1RouteGroup.GET("/ping/:token/:signal", ctrl.Ping)
+	v1RouteGroup.POST("/ping/:token/:signal", ctrl.Ping)
+
+	//public status pages, html for people and json for embedding
+	ctrl.Router.GET("/status/:slug", ctrl.RenderPublicStatusPage)
+	v1RouteGroup.GET("/status/:slug", ctrl.GetPublicStatusPage)
+	v1RouteGroup.POST("/status/:slug/subscribers", ctrl.SubscribeToStatusPage)
+	//opened from the subscription emails and webhooks, the token is the authentication
+	v1RouteGroup.GET("/status-subscribers/confirm/:token", ctrl.ConfirmStatusPageSubscriber)
+	v1RouteGroup.GET("/status-subscribers/unsubscribe/:token", ctrl.UnsubscribeStatusPageSubscriber)
+	v1RouteGroup.POST("/status-subscribers/unsubscribe/:token", ctrl.UnsubscribeStatusPageSubscriber)
+
 	fullAuthV1Routes := v1RouteGroup.Group("", middlewares.HandleAuth)
 
 	//Website regitering/testing routes
 	fullAuthV1Routes.POST("/register-website", ctrl.RegisterWebsite)
 	fullAuthV1Routes.POST("/test-website", ctrl.TestWebsiteLiveliness)
 
+	//Website management routes
+	fullAuthV1Routes.GET("/websites", ctrl.ListWebsites)
+	fullAuthV1Routes.GET("/websites/:uuid", ctrl.GetWebsite)
+	fullAuthV1Routes.PATCH("/websites/:uuid", ctrl.UpdateWebsite)
+	fullAuthV1Routes.DELETE("/websites/:uuid", ctrl.DeleteWebsite)
+	fullAuthV1Routes.POST("/websites/:uuid/pause", ctrl.PauseWebsite)
+	fullAuthV1Routes.POST("/websites/:uuid/resume", ctrl.ResumeWebsite)
+	fullAuthV1Routes.GET("/websites/:uuid/stats", ctrl.GetWebsiteStats)
+	fullAuthV1Routes.GET("/websites/:uuid/incidents", ctrl.ListWebsiteIncidents)
+	fullAuthV1Routes.GET("/websites/:uuid/pings", ctrl.ListHeartbeatPings)
+	fullAuthV1Routes.GET("/websites/:uuid/step-logs", ctrl.ListWebsiteStepLogs)
+	fullAuthV1Routes.POST("/incidents/:id/ack", ctrl.AcknowledgeIncident)
+
+	//Alert config routes
+	fullAuthV1Routes.GET("/websites/:uuid/alert-config", ctrl.GetAlertConfig)
+	fullAuthV1Routes.PATCH("/websites/:uuid/alert-config", ctrl.UpdateAlertConfig)
+
+	//Alert target routes
+	fullAuthV1Routes.GET("/websites/:uuid/alert-targets", ctrl.ListAlertTargets)
+	fullAuthV1Routes.POST("/websites/:uuid/alert-targets", ctrl.CreateAlertTarget)
+	fullAuthV1Routes.POST("/websites/:uuid/alert-targets/:id/activate", ctrl.ActivateAlertTarget)
+	fullAuthV1Routes.POST("/websites/:uuid/alert-targets/:id/deactivate", ctrl.DeactivateAlertTarget)
+	fullAuthV1Routes.DELETE("/websites/:uuid/alert-targets/:id", ctrl.DeleteAlertTarget)
+
+	//Maintenance window routes
+	fullAuthV1Routes.GET("/maintenance-windows", ctrl.ListMaintenanceWindows)
+	fullAuthV1Routes.POST("/maintenance-windows", ctrl.CreateMaintenanceWindow)
+	fullAuthV1Routes.GET("/maintenance-windows/:uuid", ctrl.GetMaintenanceWindow)
+	fullAuthV1Routes.PATCH("/maintenance-windows/:uuid", ctrl.UpdateMaintenanceWindow)
+	fullAuthV1Routes.DELETE("/maintenance-windows/:uuid", ctrl.DeleteMaintenanceWindow)
+
+	//Status page routes
+	fullAuthV1Routes.GET("/status-pages", ctrl.ListStatusPages)
+	fullAuthV1Routes.POST("/status-pages", ctrl.CreateStatusPage)
+	fullAuthV1Routes.GET("/status-pages/:uuid", ctrl.GetStatusPage)
+	fullAuthV1Routes.PATCH("/status-pages/:uuid", ctrl.UpdateStatusPage)
+	fullAuthV1Routes.DELETE("/status-pages/:uuid", ctrl.DeleteStatusPage)
+	fullAuthV1Routes.GET("/status-pages/:uuid/subscribers", ctrl.ListStatusPageSubscribers)
+	fullAuthV1Routes.DELETE("/status-pages/:uuid/subscribers/:id", ctrl.DeleteStatusPageSubscriber)
+
+	//Admin routes
+	adminV1Routes := fullAuthV1Routes.Group("/admin", middlewares.HandleAdmin)
+	adminV1Routes.GET("/incident-events", ctrl.ListIncidentEvents)
+	adminV1Routes.POST("/incident-events/:uuid/replay", ctrl.ReplayIncidentEvent)
+
 	logger.Info("Initializing Routes : Success.....")
 }
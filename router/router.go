@@ -9,6 +9,9 @@ import (
 
 // RegisterRoutes add all routing list here automatically get main router
 func RegisterRoutes(ctrl controllers.BaseController) {
+	//status pages on a custom domain are served before routing, see ServeStatusPageDomain
+	ctrl.Router.Use(ctrl.ServeStatusPageDomain)
+
 	ctrl.Router.NoRoute(func(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "Route Not Found"})
 	})
@@ -1,7 +1,18 @@
 package utils
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"syscall"
 	"time"
 
 	"github.com/ankur12345678/uptime-monitor/pkg/constants"
@@ -51,6 +62,140 @@ func UUIDGen(category string) string {
 		return fmt.Sprintf("web_%s", id)
 	case constants.INCIDENT_EVENT_TYPE:
 		return fmt.Sprintf("ie_%s", id)
+	case constants.MAINTENANCE_WINDOW_TYPE:
+		return fmt.Sprintf("mw_%s", id)
+	case constants.STATUS_PAGE_TYPE:
+		return fmt.Sprintf("sp_%s", id)
+	case constants.SUBSCRIBER_NOTIFICATION_TYPE:
+		return fmt.Sprintf("sn_%s", id)
 	}
 	return ""
 }
+
+// GenerateToken returns a random url safe token, used for links which act as their own authentication.
+func GenerateToken() (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// incidentAckPayload is what gets signed in the acknowledgement links
+func incidentAckPayload(incidentID uint, expiresAt int64) string {
+	return fmt.Sprintf("incident-ack:%d:%d", incidentID, expiresAt)
+}
+
+// SignHMAC returns the hex encoded HMAC-SHA256 of payload
+func SignHMAC(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedIncidentAckURL builds a link which acknowledges the incident without logging in, until expiresAt.
+func SignedIncidentAckURL(baseURL, secret string, incidentID uint, expiresAt time.Time) string {
+	expires := expiresAt.Unix()
+	return fmt.Sprintf("%s/v1/incidents/%d/ack?expires=%d&signature=%s", baseURL, incidentID, expires, SignHMAC(secret, incidentAckPayload(incidentID, expires)))
+}
+
+// VerifyIncidentAckSignature checks a link built by SignedIncidentAckURL, no link is valid without a secret.
+func VerifyIncidentAckSignature(secret string, incidentID uint, expires int64, signature string) bool {
+	if secret == "" || time.Now().Unix() > expires {
+		return false
+	}
+	expected := SignHMAC(secret, incidentAckPayload(incidentID, expires))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// EncryptString seals plainText with AES-GCM using a key derived from secret and returns it base64 encoded.
+func EncryptString(secret string, plainText string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plainText), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString reverses EncryptString.
+func DecryptString(secret string, cipherText string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("cipher text too short")
+	}
+
+	plainText, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plainText), nil
+}
+
+func newGCM(secret string) (cipher.AEAD, error) {
+	if secret == "" {
+		return nil, errors.New("encryption key is not configured")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ErrNonPublicAddress is returned for connections to loopback, private or otherwise internal addresses
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// carrier grade nat range, not covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || sharedAddressSpace.Contains(ip))
+}
+
+// NewPublicHTTPClient only connects to public addresses, it is used for urls given by anonymous users (eg. status page
+// subscribers) which must not reach the internal network. The check runs on the resolved address of every connection,
+// redirects and dns rebinding included.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return ErrNonPublicAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	//a proxy would make the connection on our behalf, skipping the check
+	transport.Proxy = nil
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}